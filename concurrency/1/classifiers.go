@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/awnzl/workshops/concurrency/1/classify"
+)
+
+//go:embed keywords.json
+var defaultKeywords []byte
+
+const (
+	// bayesPrefix tells the labels of the naive Bayes model from the others.
+	bayesPrefix = "bayes:"
+	// otherLabel is the model's catch-all class, it isn't a topic.
+	otherLabel = "other"
+)
+
+// training are labeled tweets for the naive Bayes classifier.
+var training = []classify.Example{
+	{Text: "Goroutines and channels make concurrency in golang easy", Label: "go"},
+	{Text: "Just released a new version of my go library with modules support", Label: "go"},
+	{Text: "The gopher plush arrived, go team!", Label: "go"},
+	{Text: "Profiling a go service with pprof today", Label: "go"},
+	{Text: "Writing CSS all day, flexbox to the rescue", Label: otherLabel},
+	{Text: "Coffee first, then the standup meeting", Label: otherLabel},
+	{Text: "My cat decided the keyboard is a bed", Label: otherLabel},
+	{Text: "Frontend frameworks come and go, html stays", Label: otherLabel},
+}
+
+// ruleDefs are the regular expression rules. The labels of the rules telling
+// the form of a tweet rather than its subject aren't topics.
+var ruleDefs = []struct {
+	label, pattern string
+	confidence     float64
+	topic          bool
+}{
+	{"link", `https?://\S+`, 1, false},
+	{"go", `(?i)\bgo\s+(?:modules|generics|routines?)\b`, 0.9, true},
+	{"question", `\?\s*$`, 0.6, false},
+}
+
+// newClassifier combines the keyword sets from the JSON file at path, the
+// embedded keywords.json when it's empty, with the rules, the tags and a
+// naive Bayes model trained on the examples above.
+func newClassifier(path string) (classify.Classifier, error) {
+	var r io.Reader = bytes.NewReader(defaultKeywords)
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	keywords, err := classify.LoadKeywords(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules classify.Rules
+	for _, r := range ruleDefs {
+		rule, err := classify.NewRule(r.label, r.pattern, r.confidence)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	nb := classify.NewNaiveBayes()
+	nb.Train(training...)
+
+	return classify.Combine(keywords, rules, classify.Tags{}, bayesLabels{nb}), nil
+}
+
+// bayesLabels keeps the most probable label of the model, prefixed to
+// tell it from the keyword labels.
+type bayesLabels struct {
+	classify.Classifier
+}
+
+func (b bayesLabels) Classify(text string) []classify.Label {
+	labels := b.Classifier.Classify(text)
+	if len(labels) == 0 {
+		return nil
+	}
+	top := labels[0]
+	top.Name = bayesPrefix + top.Name
+	return []classify.Label{top}
+}
+
+// topics are the subjects the labels tell about, for the windows: a Bayes
+// label is the keyword label of the same name, counted once, and neither
+// the catch-all class, the hashtags and mentions nor the rule labels of
+// the form are topics.
+func topics(labels []classify.Label) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, l := range labels {
+		name := strings.TrimPrefix(l.Name, bayesPrefix)
+		if l.Confidence < 0.5 || seen[name] || !isTopic(name) {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+func isTopic(name string) bool {
+	if name == otherLabel || strings.HasPrefix(name, "#") || strings.HasPrefix(name, "@") {
+		return false
+	}
+	for _, r := range ruleDefs {
+		if r.label == name {
+			return r.topic
+		}
+	}
+	return true
+}
+
+func formatLabels(labels []classify.Label) string {
+	var buf bytes.Buffer
+	for i, l := range labels {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s %.2f", l.Name, l.Confidence)
+	}
+	return buf.String()
+}
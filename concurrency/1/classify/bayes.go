@@ -0,0 +1,91 @@
+package classify
+
+import (
+	"math"
+	"sync"
+)
+
+// Example is a labeled text to train a NaiveBayes on.
+type Example struct {
+	Text  string
+	Label string
+}
+
+// NaiveBayes is a multinomial naive Bayes model over the Tokens of texts,
+// with add-one smoothing. It labels a text with every label it was trained
+// on, the confidence is the probability of the label given the text.
+type NaiveBayes struct {
+	mu sync.RWMutex
+	// docs and tokens count the examples and the tokens per label
+	docs   map[string]int
+	tokens map[string]int
+	counts map[string]map[string]int
+	vocab  map[string]bool
+	total  int
+}
+
+func NewNaiveBayes() *NaiveBayes {
+	return &NaiveBayes{
+		docs:   map[string]int{},
+		tokens: map[string]int{},
+		counts: map[string]map[string]int{},
+		vocab:  map[string]bool{},
+	}
+}
+
+// Train adds the examples to the model, it can be trained more later.
+func (nb *NaiveBayes) Train(examples ...Example) {
+	nb.mu.Lock()
+	defer nb.mu.Unlock()
+
+	for _, ex := range examples {
+		if nb.counts[ex.Label] == nil {
+			nb.counts[ex.Label] = map[string]int{}
+		}
+		nb.docs[ex.Label]++
+		nb.total++
+		for _, tok := range Tokens(ex.Text) {
+			nb.counts[ex.Label][tok]++
+			nb.tokens[ex.Label]++
+			nb.vocab[tok] = true
+		}
+	}
+}
+
+func (nb *NaiveBayes) Classify(text string) []Label {
+	nb.mu.RLock()
+	defer nb.mu.RUnlock()
+
+	if nb.total == 0 {
+		return nil
+	}
+	tokens := Tokens(text)
+	vocab := float64(len(nb.vocab))
+
+	// log P(label) + sum log P(token|label), the tokens never seen are skipped
+	logs := make(map[string]float64, len(nb.docs))
+	max := math.Inf(-1)
+	for label, docs := range nb.docs {
+		lp := math.Log(float64(docs) / float64(nb.total))
+		for _, tok := range tokens {
+			if !nb.vocab[tok] {
+				continue
+			}
+			lp += math.Log(float64(nb.counts[label][tok]+1) / (float64(nb.tokens[label]) + vocab))
+		}
+		logs[label] = lp
+		max = math.Max(max, lp)
+	}
+
+	// normalize, subtracting the max keeps exp from underflowing
+	var sum float64
+	for _, lp := range logs {
+		sum += math.Exp(lp - max)
+	}
+	labels := make([]Label, 0, len(logs))
+	for label, lp := range logs {
+		labels = append(labels, Label{label, math.Exp(lp-max) / sum})
+	}
+	sortLabels(labels)
+	return labels
+}
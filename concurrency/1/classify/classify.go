@@ -0,0 +1,65 @@
+// Package classify labels texts like tweets. A Classifier returns the
+// labels it finds with its confidence in them, the implementations are
+// keyword sets, regular expression rules, hashtags and mentions, and a
+// naive Bayes model trained on labeled texts.
+package classify
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Label is a class of a text with the confidence in it, from 0 to 1.
+type Label struct {
+	Name       string
+	Confidence float64
+}
+
+type Classifier interface {
+	// Classify returns the labels of text, the most confident first.
+	Classify(text string) []Label
+}
+
+// Combine returns a classifier with the labels of all cs, a label found by
+// several of them has its highest confidence.
+func Combine(cs ...Classifier) Classifier {
+	return combined(cs)
+}
+
+type combined []Classifier
+
+func (cs combined) Classify(text string) []Label {
+	best := map[string]float64{}
+	for _, c := range cs {
+		for _, l := range c.Classify(text) {
+			if conf, ok := best[l.Name]; !ok || l.Confidence > conf {
+				best[l.Name] = l.Confidence
+			}
+		}
+	}
+	labels := make([]Label, 0, len(best))
+	for name, conf := range best {
+		labels = append(labels, Label{name, conf})
+	}
+	sortLabels(labels)
+	return labels
+}
+
+// sortLabels sorts by confidence, then by name for a stable output.
+func sortLabels(labels []Label) {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Confidence != labels[j].Confidence {
+			return labels[i].Confidence > labels[j].Confidence
+		}
+		return labels[i].Name < labels[j].Name
+	})
+}
+
+// Tokens returns the lowercased words of text, hashtags and mentions
+// without their # and @.
+func Tokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
@@ -0,0 +1,114 @@
+package classify
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func format(labels []Label) string {
+	s := make([]string, len(labels))
+	for i, l := range labels {
+		s[i] = fmt.Sprintf("%s %.2f", l.Name, l.Confidence)
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestKeywords(t *testing.T) {
+	k, err := LoadKeywords(strings.NewReader(`{"go": ["golang", "Gopher"], "coffee": ["espresso"], "frontend": ["css", "Node.js"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, want := range map[string]string{
+		"#golang meetup for the gopher": "go 0.75",
+		"GOLANG and espresso":           "coffee 0.50, go 0.50",
+		"nothing here":                  "",
+		"access for gophers":            "",
+		"node.js, CSS":                  "frontend 0.75",
+		"node js":                       "frontend 0.50",
+		"js node":                       "",
+	} {
+		if got := format(k.Classify(text)); got != want {
+			t.Errorf("%q: expected %q, got %q", text, want, got)
+		}
+	}
+
+	for _, config := range []string{`{"go": [""]}`, `{"go": ["++"]}`, `["golang"]`} {
+		if _, err := LoadKeywords(strings.NewReader(config)); err == nil {
+			t.Errorf("%s: expected an error", config)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	link, err := NewRule("link", `https?://\S+`, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	question, err := NewRule("question", `\?\s*$`, 0.6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := Rules{question, link}
+	if got := format(rules.Classify("seen https://go.dev ?")); got != "link 0.90, question 0.60" {
+		t.Errorf("unexpected labels %q", got)
+	}
+	if _, err := NewRule("bad", "(", 1); err == nil {
+		t.Error("expected an error for a bad pattern")
+	}
+}
+
+func TestTags(t *testing.T) {
+	text := "Looking forward to the #Gopher meetup with @ironzeb! #gopher a#b @ #go_lang,@x"
+	if got, want := Hashtags(text), []string{"#gopher", "#gopher", "#go_lang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected hashtags %q, got %q", want, got)
+	}
+	if got, want := Mentions(text), []string{"@ironzeb", "@x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected mentions %q, got %q", want, got)
+	}
+	if got := format(Tags{}.Classify(text)); got != "#go_lang 1.00, #gopher 1.00, @ironzeb 1.00, @x 1.00" {
+		t.Errorf("unexpected labels %q", got)
+	}
+}
+
+func TestNaiveBayes(t *testing.T) {
+	nb := NewNaiveBayes()
+	if labels := nb.Classify("anything"); labels != nil {
+		t.Errorf("expected no labels before training, got %v", labels)
+	}
+	nb.Train(
+		Example{"goroutines and channels in golang", "go"},
+		Example{"the gopher mascot at the go meetup", "go"},
+		Example{"go modules and generics", "go"},
+		Example{"centering a div with css", "frontend"},
+		Example{"react hooks and css grid", "frontend"},
+	)
+
+	labels := nb.Classify("channels and goroutines")
+	if len(labels) != 2 || labels[0].Name != "go" || labels[0].Confidence < 0.8 {
+		t.Errorf("expected go first and confident, got %q", format(labels))
+	}
+	if sum := labels[0].Confidence + labels[1].Confidence; sum < 0.999 || sum > 1.001 {
+		t.Errorf("expected probabilities adding up to 1, got %f", sum)
+	}
+	if labels := nb.Classify("CSS!"); labels[0].Name != "frontend" {
+		t.Errorf("expected frontend first, got %q", format(labels))
+	}
+	// unknown words leave the priors, 3 of 5 examples are go
+	if got := format(nb.Classify("unrelated words")); got != "go 0.60, frontend 0.40" {
+		t.Errorf("expected the priors, got %q", got)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	c := Combine(
+		Keywords{"go": {"golang"}},
+		Rules{{Label: "go", Pattern: regexp.MustCompile(`(?i)gopher`), Confidence: 0.9}},
+		Tags{},
+	)
+	if got := format(c.Classify("#golang gopher")); got != "#golang 1.00, go 0.90" {
+		t.Errorf("unexpected labels %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package classify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Keywords labels the texts containing keywords of the label, ignoring
+// case. Keywords match whole Tokens, so "css" doesn't match "access", and a
+// keyword of several tokens matches them in a row. Each keyword found
+// halves the doubt: one gives 0.5, two 0.75.
+type Keywords map[string][]string
+
+// LoadKeywords reads keywords from a JSON object of labels to their
+// keywords, like {"go": ["golang", "gopher"]}.
+func LoadKeywords(r io.Reader) (Keywords, error) {
+	var k Keywords
+	if err := json.NewDecoder(r).Decode(&k); err != nil {
+		return nil, fmt.Errorf("load keywords: %w", err)
+	}
+	for label, words := range k {
+		for _, w := range words {
+			if len(Tokens(w)) == 0 {
+				return nil, fmt.Errorf("load keywords: keyword %q for %s has no tokens", w, label)
+			}
+		}
+	}
+	return k, nil
+}
+
+func (k Keywords) Classify(text string) []Label {
+	tokens := Tokens(text)
+	var labels []Label
+	for label, words := range k {
+		found := 0
+		for _, w := range words {
+			if containsRun(tokens, Tokens(w)) {
+				found++
+			}
+		}
+		if found > 0 {
+			labels = append(labels, Label{label, 1 - math.Pow(0.5, float64(found))})
+		}
+	}
+	sortLabels(labels)
+	return labels
+}
+
+// containsRun reports whether tokens has the non-empty run in a row.
+func containsRun(tokens, run []string) bool {
+	if len(run) == 0 {
+		return false
+	}
+	for i := 0; i+len(run) <= len(tokens); i++ {
+		j := 0
+		for j < len(run) && tokens[i+j] == run[j] {
+			j++
+		}
+		if j == len(run) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package classify
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Rule labels the texts matching Pattern with a fixed confidence.
+type Rule struct {
+	Label      string
+	Pattern    *regexp.Regexp
+	Confidence float64
+}
+
+// NewRule compiles pattern into a rule.
+func NewRule(label, pattern string, confidence float64) (Rule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule %s: %w", label, err)
+	}
+	return Rule{Label: label, Pattern: re, Confidence: confidence}, nil
+}
+
+// Rules labels a text with each rule it matches.
+type Rules []Rule
+
+func (rs Rules) Classify(text string) []Label {
+	var labels []Label
+	for _, r := range rs {
+		if r.Pattern.MatchString(text) {
+			labels = append(labels, Label{r.Label, r.Confidence})
+		}
+	}
+	sortLabels(labels)
+	return labels
+}
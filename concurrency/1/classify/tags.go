@@ -0,0 +1,62 @@
+package classify
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tags labels a text with its hashtags and mentions, like "#golang" and
+// "@ironzeb", lowercased and with full confidence.
+type Tags struct{}
+
+func (Tags) Classify(text string) []Label {
+	var labels []Label
+	seen := map[string]bool{}
+	for _, tag := range append(Hashtags(text), Mentions(text)...) {
+		if !seen[tag] {
+			seen[tag] = true
+			labels = append(labels, Label{tag, 1})
+		}
+	}
+	sortLabels(labels)
+	return labels
+}
+
+// Hashtags returns the hashtags of text, lowercased with their #.
+func Hashtags(text string) []string {
+	return prefixed(text, '#')
+}
+
+// Mentions returns the mentioned users of text, lowercased with their @.
+func Mentions(text string) []string {
+	return prefixed(text, '@')
+}
+
+// prefixed returns the words starting with prefix, which isn't preceded
+// by a word character: "a#b" isn't a hashtag.
+func prefixed(text string, prefix rune) []string {
+	var words []string
+	prev := ' '
+	for i, r := range text {
+		if r == prefix && !isWord(prev) {
+			end := i + utf8.RuneLen(r)
+			for end < len(text) {
+				c, size := utf8.DecodeRuneInString(text[end:])
+				if !isWord(c) {
+					break
+				}
+				end += size
+			}
+			if end > i+1 {
+				words = append(words, strings.ToLower(text[i:end]))
+			}
+		}
+		prev = r
+	}
+	return words
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
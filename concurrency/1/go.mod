@@ -0,0 +1,3 @@
+module github.com/awnzl/workshops/concurrency/1
+
+go 1.18
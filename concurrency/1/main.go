@@ -1,43 +1,93 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"os"
 	"time"
+
+	"github.com/awnzl/workshops/concurrency/1/classify"
+	"github.com/awnzl/workshops/concurrency/1/pipeline"
+	"github.com/awnzl/workshops/concurrency/1/window"
 )
 
-func producer(stream Stream) (tweets []*Tweet) {
-	for {
-		tweet, err := stream.Next()
-		if err == ErrEOF {
-			return tweets
+// producer streams the tweets while they're read.
+func producer(p *pipeline.Pipeline, stream *Stream) <-chan *Tweet {
+	return pipeline.Source(p, pipeline.Options{Name: "producer"}, func(ctx context.Context, emit func(*Tweet) error) error {
+		for {
+			tweet, err := stream.Next()
+			if err == ErrEOF {
+				return nil
+			}
+			if err := emit(tweet); err != nil {
+				return err
+			}
 		}
-		// TODO: use channel here
-		tweets = append(tweets, tweet)
-	}
+	})
 }
 
-func consumer(tweets []*Tweet) {
-	// TODO: use channel here
-	for _, t := range tweets {
-		if t.IsTalkingAboutGo() {
-			fmt.Println(t.Username, "\ttweets about golang")
-			continue
-		}
+type labeled struct {
+	tweet  *Tweet
+	labels []classify.Label
+}
+
+// consumer labels the tweets in parallel, printing them in the stream's order,
+// and counts them in the windows of agg.
+func consumer(p *pipeline.Pipeline, tweets <-chan *Tweet, c classify.Classifier, agg *window.Aggregator) error {
+	results := pipeline.Stage(p, tweets, pipeline.Options{Name: "classify", Workers: 4, Ordered: true},
+		func(ctx context.Context, t *Tweet) (labeled, error) {
+			return labeled{t, c.Classify(t.Text)}, nil
+		})
+
+	err := pipeline.Sink(p, results, pipeline.Options{Name: "print"}, func(ctx context.Context, l labeled) error {
+		fmt.Printf("%s\t%s\n", l.tweet.Username, formatLabels(l.labels))
+		return agg.Add(event(l))
+	})
+	if err != nil {
+		return err
+	}
+	return agg.Flush()
+}
 
-		fmt.Println(t.Username, "\tdoes not tweet about golang")
+// event is the tweet for the windows.
+func event(l labeled) window.Event {
+	return window.Event{
+		Time:     l.tweet.Time,
+		User:     l.tweet.Username,
+		Topics:   topics(l.labels),
+		Hashtags: classify.Hashtags(l.tweet.Text),
 	}
 }
 
 func main() {
+	keywords := flag.String("keywords", "", "JSON file of labels to their keywords, keywords.json by default")
+	var cfg window.Config
+	flag.DurationVar(&cfg.Size, "window", time.Minute, "length of the windows, in tweet time")
+	flag.DurationVar(&cfg.Slide, "slide", 0, "time between the starts of sliding windows, tumbling windows when unset")
+	flag.DurationVar(&cfg.Lateness, "lateness", 30*time.Second, "how late a tweet can arrive and still be counted")
+	flag.IntVar(&cfg.TopN, "top", 3, "number of top hashtags per window")
+	flag.Parse()
+
+	c, err := newClassifier(*keywords)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	agg, err := window.New(cfg, window.WriterSink{W: os.Stdout})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	start := time.Now()
 	stream := GetMockStream()
 
-	// Modification starts from here
-	// Hint: this can be resolved via channels
-	// Producer
-	tweets := producer(stream)
-	// Consumer
-	consumer(tweets)
+	p := pipeline.New(context.Background())
+	if err := consumer(p, producer(p, &stream), c, agg); err != nil {
+		fmt.Println(err)
+	}
 
 	fmt.Printf("Process took %s\n", time.Since(start))
 }
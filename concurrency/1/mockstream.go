@@ -47,6 +47,9 @@ func (s *Stream) Next() (*Tweet, error) {
 type Tweet struct {
 	Username string
 	Text     string
+	// Time is when the tweet was posted, the stream doesn't deliver
+	// the tweets strictly in that order.
+	Time time.Time
 }
 
 // IsTalkingAboutGo is a mock process which pretend to be a sophisticated procedure to analyse whether tweet is talking about go or not
@@ -60,21 +63,29 @@ func (t *Tweet) IsTalkingAboutGo() bool {
 	return hasGolang || hasGopher
 }
 
+// mockEpoch is the time of the mock tweets.
+var mockEpoch = time.Date(2021, 10, 5, 18, 0, 0, 0, time.UTC)
+
 var mockdata = []Tweet{
 	{
 		"davecheney",
 		"#golang top tip: if your unit tests import any other package you wrote, including themselves, they're not unit tests.",
+		mockEpoch.Add(10 * time.Second),
 	}, {
 		"beertocode",
 		"Backend developer, doing frontend featuring the eternal struggle of centering something. #coding",
+		mockEpoch.Add(40 * time.Second),
 	}, {
 		"ironzeb",
 		"Re: Popularity of Golang in China: My thinking nowadays is that it had a lot to do with this book and author https://github.com/astaxie/build-web-application-with-golang",
+		mockEpoch.Add(65 * time.Second),
 	}, {
 		"beertocode",
 		"Looking forward to the #gopher meetup in Hsinchu tonight with @ironzeb!",
+		mockEpoch.Add(-30 * time.Second),
 	}, {
 		"vampirewalk666",
 		"I just wrote a golang slack bot! It reports the state of github repository. #Slack #golang",
+		mockEpoch.Add(150 * time.Second),
 	},
 }
@@ -0,0 +1,223 @@
+// Package pipeline runs streams of values through stages connected by
+// bounded channels: a source, any number of stages each with its own
+// workers, and a sink.
+//
+//	p := pipeline.New(ctx)
+//	lines := pipeline.Source(p, pipeline.Options{Name: "read"}, read)
+//	words := pipeline.Stage(p, lines, pipeline.Options{Name: "count", Workers: 4, Ordered: true}, count)
+//	err := pipeline.Sink(p, words, pipeline.Options{Name: "print"}, print)
+//
+// The first error of any stage cancels the others and is returned by Sink.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Pipeline is the shared state of the stages of one run.
+type Pipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  error
+}
+
+// New returns a pipeline canceled with ctx.
+func New(ctx context.Context) *Pipeline {
+	p := &Pipeline{parent: ctx}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return p
+}
+
+// Context is canceled when the pipeline fails or ctx is canceled.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// fail records the first error and stops the stages.
+func (p *Pipeline) fail(name string, err error) {
+	p.once.Do(func() {
+		if name != "" {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+		p.err = err
+		p.cancel()
+	})
+}
+
+// Wait waits for the stages to return, and returns the first error.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	if p.err != nil {
+		return p.err
+	}
+	return p.parent.Err()
+}
+
+func (p *Pipeline) goFunc(f func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		f()
+	}()
+}
+
+// Options configure a stage, Workers and Ordered only apply to Stage.
+type Options struct {
+	// Name prefixes the stage's errors.
+	Name string
+	// Workers is the number of goroutines calling the stage's func, 1 when unset.
+	Workers int
+	// Buffer is the capacity of the stage's output channel.
+	Buffer int
+	// Ordered keeps the order of the input in the output of a stage with
+	// several workers, at most Workers+Buffer values are in flight.
+	Ordered bool
+}
+
+func (o Options) workers() int {
+	if o.Workers < 1 {
+		return 1
+	}
+	return o.Workers
+}
+
+// send sends v unless the pipeline is stopped.
+func send[T any](ctx context.Context, out chan<- T, v T) error {
+	select {
+	case out <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Source runs gen, sending the values it emits to the returned channel,
+// which is closed when gen returns. emit fails when the pipeline stopped,
+// gen should return then.
+func Source[T any](p *Pipeline, opts Options, gen func(ctx context.Context, emit func(T) error) error) <-chan T {
+	out := make(chan T, opts.Buffer)
+	p.goFunc(func() {
+		defer close(out)
+		emit := func(v T) error { return send(p.ctx, out, v) }
+		if err := gen(p.ctx, emit); err != nil && p.ctx.Err() == nil {
+			p.fail(opts.Name, err)
+		}
+	})
+	return out
+}
+
+// Stage calls f on each value of in from opts.Workers goroutines, and sends
+// the results to the returned channel, which is closed with in. Errors
+// of f stop the pipeline.
+func Stage[In, Out any](p *Pipeline, in <-chan In, opts Options, f func(ctx context.Context, v In) (Out, error)) <-chan Out {
+	out := make(chan Out, opts.Buffer)
+	if opts.Ordered && opts.workers() > 1 {
+		ordered(p, in, out, opts, f)
+		return out
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < opts.workers(); i++ {
+		workers.Add(1)
+		p.goFunc(func() {
+			defer workers.Done()
+			for v := range in {
+				if p.ctx.Err() != nil {
+					return
+				}
+				res, err := f(p.ctx, v)
+				if err != nil {
+					p.fail(opts.Name, err)
+					return
+				}
+				if send(p.ctx, out, res) != nil {
+					return
+				}
+			}
+		})
+	}
+	p.goFunc(func() {
+		workers.Wait()
+		close(out)
+	})
+	return out
+}
+
+type result[T any] struct {
+	v   T
+	err error
+}
+
+// ordered runs a stage keeping the order: each value gets a channel for its
+// result, queued in input order for the collector to wait on. The size
+// of the queue bounds the values in flight.
+func ordered[In, Out any](p *Pipeline, in <-chan In, out chan<- Out, opts Options, f func(context.Context, In) (Out, error)) {
+	type job struct {
+		v   In
+		res chan result[Out]
+	}
+	jobs := make(chan job)
+	queue := make(chan chan result[Out], opts.workers()+opts.Buffer)
+
+	p.goFunc(func() {
+		defer close(jobs)
+		defer close(queue)
+		for v := range in {
+			j := job{v: v, res: make(chan result[Out], 1)}
+			if send(p.ctx, queue, j.res) != nil || send(p.ctx, jobs, j) != nil {
+				return
+			}
+		}
+	})
+
+	for i := 0; i < opts.workers(); i++ {
+		p.goFunc(func() {
+			for j := range jobs {
+				v, err := f(p.ctx, j.v)
+				j.res <- result[Out]{v, err}
+			}
+		})
+	}
+
+	p.goFunc(func() {
+		defer close(out)
+		for res := range queue {
+			var r result[Out]
+			select {
+			case r = <-res:
+			case <-p.ctx.Done():
+				return
+			}
+			if r.err != nil {
+				p.fail(opts.Name, r.err)
+				return
+			}
+			if send(p.ctx, out, r.v) != nil {
+				return
+			}
+		}
+	})
+}
+
+// Sink calls f on each value of in until it's closed, then waits for the
+// pipeline and returns its first error. It returns early when f fails.
+func Sink[T any](p *Pipeline, in <-chan T, opts Options, f func(ctx context.Context, v T) error) error {
+	for v := range in {
+		if p.ctx.Err() != nil {
+			break
+		}
+		if err := f(p.ctx, v); err != nil {
+			p.fail(opts.Name, err)
+			break
+		}
+	}
+	// the stages don't block sending once the pipeline is stopped
+	return p.Wait()
+}
@@ -0,0 +1,189 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func numbers(n int) func(context.Context, func(int) error) error {
+	return func(ctx context.Context, emit func(int) error) error {
+		for i := 0; i < n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// slowSquare takes a random time, so the workers finish out of order.
+func slowSquare(ctx context.Context, v int) (int, error) {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+	return v * v, nil
+}
+
+func TestOrdered(t *testing.T) {
+	p := New(context.Background())
+	in := Source(p, Options{}, numbers(200))
+	out := Stage(p, in, Options{Workers: 8, Ordered: true}, slowSquare)
+
+	var got []int
+	err := Sink(p, out, Options{}, func(ctx context.Context, v int) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 200 {
+		t.Fatalf("expected 200 values, got %d", len(got))
+	}
+	for i, v := range got {
+		if v != i*i {
+			t.Fatalf("expected %d at %d, got %d", i*i, i, v)
+		}
+	}
+}
+
+func TestUnordered(t *testing.T) {
+	p := New(context.Background())
+	in := Source(p, Options{Buffer: 4}, numbers(200))
+	squares := Stage(p, in, Options{Workers: 8}, slowSquare)
+	wide := Stage(p, squares, Options{}, func(ctx context.Context, v int) (int64, error) { return int64(v), nil })
+
+	seen := map[int64]bool{}
+	err := Sink(p, wide, Options{}, func(ctx context.Context, v int64) error {
+		seen[v] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		if !seen[int64(i*i)] {
+			t.Fatalf("missing %d", i*i)
+		}
+	}
+}
+
+func TestParallel(t *testing.T) {
+	p := New(context.Background())
+	in := Source(p, Options{}, numbers(8))
+	out := Stage(p, in, Options{Workers: 8, Ordered: true}, func(ctx context.Context, v int) (int, error) {
+		time.Sleep(50 * time.Millisecond)
+		return v, nil
+	})
+
+	start := time.Now()
+	if err := Sink(p, out, Options{}, func(context.Context, int) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 300*time.Millisecond {
+		t.Errorf("expected the workers to run in parallel, took %s", d)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	for _, ordered := range []bool{false, true} {
+		var calls int32
+		p := New(context.Background())
+		// an endless source, it has to be stopped by the error
+		in := Source(p, Options{}, func(ctx context.Context, emit func(int) error) error {
+			for i := 0; ; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+		})
+		out := Stage(p, in, Options{Name: "check", Workers: 4, Ordered: ordered}, func(ctx context.Context, v int) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			if v == 10 {
+				return 0, errBoom
+			}
+			return v, nil
+		})
+		err := Sink(p, out, Options{}, func(context.Context, int) error { return nil })
+		if !errors.Is(err, errBoom) || err.Error() != "check: boom" {
+			t.Errorf("ordered %v: expected the stage error, got %v", ordered, err)
+		}
+	}
+
+	p := New(context.Background())
+	in := Source(p, Options{}, numbers(100))
+	err := Sink(p, in, Options{Name: "save"}, func(ctx context.Context, v int) error {
+		if v == 3 {
+			return errBoom
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "save: boom" {
+		t.Errorf("expected the sink error, got %v", err)
+	}
+
+	p = New(context.Background())
+	in = Source(p, Options{Name: "read"}, func(ctx context.Context, emit func(int) error) error {
+		emit(1)
+		return errBoom
+	})
+	err = Sink(p, in, Options{}, func(context.Context, int) error { return nil })
+	if err == nil || err.Error() != "read: boom" {
+		t.Errorf("expected the source error, got %v", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	in := Source(p, Options{}, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	})
+	out := Stage(p, in, Options{Workers: 2, Ordered: true}, slowSquare)
+	err := Sink(p, out, Options{}, func(ctx context.Context, v int) error {
+		if v > 100 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestBounded checks a slow sink holds back the source.
+func TestBounded(t *testing.T) {
+	var emitted int32
+	p := New(context.Background())
+	in := Source(p, Options{Buffer: 2}, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; i < 100; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+			atomic.AddInt32(&emitted, 1)
+		}
+		return nil
+	})
+	out := Stage(p, in, Options{Workers: 3, Buffer: 1, Ordered: true}, func(ctx context.Context, v int) (int, error) { return v, nil })
+
+	err := Sink(p, out, Options{}, func(ctx context.Context, v int) error {
+		if v == 0 {
+			time.Sleep(50 * time.Millisecond)
+			// source buffer, dispatcher, queue, workers, collector and output
+			if n := atomic.LoadInt32(&emitted); n > 16 {
+				t.Errorf("expected the source to wait, it emitted %d", n)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+package window
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriterSink writes the windows and the late events as lines of text.
+type WriterSink struct {
+	W io.Writer
+}
+
+func (s WriterSink) Window(r Result) error {
+	var top []string
+	for _, c := range r.Top {
+		top = append(top, fmt.Sprintf("%s %d", c.Tag, c.Count))
+	}
+	users := make([]string, 0, len(r.Mentions))
+	for user := range r.Mentions {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	var mentions []string
+	for _, user := range users {
+		topics := make([]string, 0, len(r.Mentions[user]))
+		for topic, n := range r.Mentions[user] {
+			topics = append(topics, fmt.Sprintf("%s %d", topic, n))
+		}
+		sort.Strings(topics)
+		mentions = append(mentions, user+" ("+strings.Join(topics, ", ")+")")
+	}
+
+	_, err := fmt.Fprintf(s.W, "window %s-%s: %d tweets, top hashtags: %s, topics: %s\n",
+		r.Start.Format("15:04:05"), r.End.Format("15:04:05"), r.Events,
+		orNone(strings.Join(top, ", ")), orNone(strings.Join(mentions, ", ")))
+	return err
+}
+
+func (s WriterSink) Late(e Event) error {
+	_, err := fmt.Fprintf(s.W, "late tweet of %s at %s, dropped\n", e.User, e.Time.Format("15:04:05"))
+	return err
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,184 @@
+// Package window aggregates a stream of tweets over event time windows:
+// the mentions of topics per user and the top hashtags of each window.
+//
+// Windows are tumbling, one after the other, or sliding, overlapping when
+// Slide is shorter than Size. A window is emitted to the Sink once the
+// watermark, the latest event time seen minus the allowed Lateness, passes
+// its end. Events arriving after all their windows were emitted are late,
+// they go to the Sink apart.
+package window
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Event is a tweet reduced to what the windows count.
+type Event struct {
+	Time     time.Time
+	User     string
+	Topics   []string
+	Hashtags []string
+}
+
+// Count is a hashtag and the number of its uses.
+type Count struct {
+	Tag   string
+	Count int
+}
+
+// Result is the aggregate of the events of a window.
+type Result struct {
+	Start, End time.Time
+	Events     int
+	// Mentions counts the topics per user.
+	Mentions map[string]map[string]int
+	// Top are the most used hashtags, most used first.
+	Top []Count
+}
+
+// Sink receives the windows in the order of their start, and the late events.
+type Sink interface {
+	Window(r Result) error
+	Late(e Event) error
+}
+
+type Config struct {
+	// Size is the length of the windows.
+	Size time.Duration
+	// Slide is the time between the starts of windows, Size when unset,
+	// which gives tumbling windows.
+	Slide time.Duration
+	// Lateness is how far behind the latest event an event can be and still
+	// be counted.
+	Lateness time.Duration
+	// TopN is the number of hashtags in results, 3 when unset.
+	TopN int
+}
+
+// Aggregator counts events into windows, it's not safe for concurrent use.
+type Aggregator struct {
+	cfg  Config
+	sink Sink
+
+	// windows are the open windows by start
+	windows   map[int64]*window
+	watermark time.Time
+}
+
+type window struct {
+	start    time.Time
+	events   int
+	mentions map[string]map[string]int
+	hashtags map[string]int
+}
+
+func New(cfg Config, sink Sink) (*Aggregator, error) {
+	if cfg.Slide == 0 {
+		cfg.Slide = cfg.Size
+	}
+	if cfg.TopN == 0 {
+		cfg.TopN = 3
+	}
+	if cfg.Size <= 0 || cfg.Slide <= 0 || cfg.Slide > cfg.Size {
+		return nil, errors.New("window: the size must be positive and the slide between 0 and the size")
+	}
+	return &Aggregator{cfg: cfg, sink: sink, windows: map[int64]*window{}}, nil
+}
+
+// Add counts e in its windows, then emits the windows the watermark passed.
+func (a *Aggregator) Add(e Event) error {
+	counted := false
+	// the windows containing e start within Size before it, on multiples of Slide
+	first := e.Time.Add(-a.cfg.Size).Truncate(a.cfg.Slide).Add(a.cfg.Slide)
+	for start := first; !start.After(e.Time); start = start.Add(a.cfg.Slide) {
+		end := start.Add(a.cfg.Size)
+		if !end.After(e.Time) {
+			continue
+		}
+		if !a.watermark.IsZero() && !end.After(a.watermark) {
+			// emitted already
+			continue
+		}
+		a.window(start).add(e)
+		counted = true
+	}
+	if !counted {
+		return a.sink.Late(e)
+	}
+
+	if wm := e.Time.Add(-a.cfg.Lateness); wm.After(a.watermark) {
+		a.watermark = wm
+	}
+	return a.emit(func(w *window) bool { return !w.start.Add(a.cfg.Size).After(a.watermark) })
+}
+
+// Flush emits the open windows, at the end of the stream.
+func (a *Aggregator) Flush() error {
+	return a.emit(func(*window) bool { return true })
+}
+
+func (a *Aggregator) window(start time.Time) *window {
+	w, ok := a.windows[start.UnixNano()]
+	if !ok {
+		w = &window{start: start, mentions: map[string]map[string]int{}, hashtags: map[string]int{}}
+		a.windows[start.UnixNano()] = w
+	}
+	return w
+}
+
+// emit sends the windows matching done to the sink by start, and forgets them.
+func (a *Aggregator) emit(done func(*window) bool) error {
+	var ready []*window
+	for _, w := range a.windows {
+		if done(w) {
+			ready = append(ready, w)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i].start.Before(ready[j].start) })
+
+	for _, w := range ready {
+		delete(a.windows, w.start.UnixNano())
+		if err := a.sink.Window(w.result(a.cfg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *window) add(e Event) {
+	w.events++
+	for _, topic := range e.Topics {
+		if w.mentions[e.User] == nil {
+			w.mentions[e.User] = map[string]int{}
+		}
+		w.mentions[e.User][topic]++
+	}
+	for _, tag := range e.Hashtags {
+		w.hashtags[tag]++
+	}
+}
+
+func (w *window) result(cfg Config) Result {
+	top := make([]Count, 0, len(w.hashtags))
+	for tag, n := range w.hashtags {
+		top = append(top, Count{tag, n})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Tag < top[j].Tag
+	})
+	if len(top) > cfg.TopN {
+		top = top[:cfg.TopN]
+	}
+	return Result{
+		Start:    w.start,
+		End:      w.start.Add(cfg.Size),
+		Events:   w.events,
+		Mentions: w.mentions,
+		Top:      top,
+	}
+}
@@ -0,0 +1,185 @@
+package window
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2021, 10, 5, 18, 0, 0, 0, time.UTC)
+
+func at(offset string) time.Time {
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		panic(err)
+	}
+	return t0.Add(d)
+}
+
+// collect keeps what it receives.
+type collect struct {
+	windows []Result
+	late    []Event
+}
+
+func (c *collect) Window(r Result) error { c.windows = append(c.windows, r); return nil }
+func (c *collect) Late(e Event) error    { c.late = append(c.late, e); return nil }
+
+func run(t *testing.T, cfg Config, events []Event) *collect {
+	t.Helper()
+	c := &collect{}
+	a, err := New(cfg, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if err := a.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func spans(rs []Result) []string {
+	var s []string
+	for _, r := range rs {
+		s = append(s, r.Start.Format("15:04:05")+"-"+r.End.Format("15:04:05"))
+	}
+	return s
+}
+
+func TestTumbling(t *testing.T) {
+	c := run(t, Config{Size: time.Minute, TopN: 2}, []Event{
+		{Time: at("10s"), User: "dave", Topics: []string{"go"}, Hashtags: []string{"#golang"}},
+		{Time: at("40s"), User: "bob", Topics: []string{"frontend"}, Hashtags: []string{"#css", "#golang"}},
+		{Time: at("50s"), User: "dave", Topics: []string{"go", "link"}, Hashtags: []string{"#gopher"}},
+		{Time: at("1m30s"), User: "ann", Hashtags: []string{"#slack"}},
+	})
+
+	if got, want := spans(c.windows), []string{"18:00:00-18:01:00", "18:01:00-18:02:00"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected windows %v, got %v", want, got)
+	}
+	first := c.windows[0]
+	if first.Events != 3 {
+		t.Errorf("expected 3 events, got %d", first.Events)
+	}
+	wantMentions := map[string]map[string]int{"dave": {"go": 2, "link": 1}, "bob": {"frontend": 1}}
+	if !reflect.DeepEqual(first.Mentions, wantMentions) {
+		t.Errorf("expected mentions %v, got %v", wantMentions, first.Mentions)
+	}
+	if want := []Count{{"#golang", 2}, {"#css", 1}}; !reflect.DeepEqual(first.Top, want) {
+		t.Errorf("expected top %v, got %v", want, first.Top)
+	}
+	if len(c.late) != 0 {
+		t.Errorf("expected no late events, got %v", c.late)
+	}
+}
+
+func TestSliding(t *testing.T) {
+	c := run(t, Config{Size: time.Minute, Slide: 30 * time.Second}, []Event{
+		{Time: at("10s"), User: "a"},
+		{Time: at("45s"), User: "b"},
+		{Time: at("1m5s"), User: "c"},
+	})
+
+	want := []string{"17:59:30-18:00:30", "18:00:00-18:01:00", "18:00:30-18:01:30", "18:01:00-18:02:00"}
+	if got := spans(c.windows); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected windows %v, got %v", want, got)
+	}
+	var counts []int
+	for _, r := range c.windows {
+		counts = append(counts, r.Events)
+	}
+	if want := []int{1, 2, 2, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("expected counts %v, got %v", want, counts)
+	}
+}
+
+func TestLateness(t *testing.T) {
+	c := &collect{}
+	a, err := New(Config{Size: time.Minute, Lateness: 30 * time.Second}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := func(offset, user string) {
+		t.Helper()
+		if err := a.Add(Event{Time: at(offset), User: user}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add("10s", "a")
+	add("1m20s", "b")
+	// the watermark is 18:00:50, the first window is still open
+	add("55s", "late but counted")
+	if len(c.windows) != 0 {
+		t.Fatalf("expected no window yet, got %v", spans(c.windows))
+	}
+	add("2m5s", "c")
+	// the watermark is 18:01:35, the first window is done
+	if got := spans(c.windows); !reflect.DeepEqual(got, []string{"18:00:00-18:01:00"}) || c.windows[0].Events != 2 {
+		t.Fatalf("expected the first window with 2 events, got %v", got)
+	}
+	add("30s", "too late")
+	if len(c.late) != 1 || c.late[0].User != "too late" {
+		t.Errorf("expected the late event, got %v", c.late)
+	}
+	// late events don't move the watermark
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := spans(c.windows); len(got) != 3 {
+		t.Errorf("expected 3 windows after the flush, got %v", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(Config{Size: time.Minute, Slide: 2 * time.Minute}, &collect{}); err == nil {
+		t.Error("expected an error for a slide longer than the size")
+	}
+	if _, err := New(Config{}, &collect{}); err == nil {
+		t.Error("expected an error without a size")
+	}
+
+	errFull := errors.New("full")
+	a, _ := New(Config{Size: time.Minute}, failing{errFull})
+	a.Add(Event{Time: at("10s")})
+	if err := a.Add(Event{Time: at("1m10s")}); !errors.Is(err, errFull) {
+		t.Errorf("expected the sink error, got %v", err)
+	}
+}
+
+type failing struct{ err error }
+
+func (f failing) Window(Result) error { return f.err }
+func (f failing) Late(Event) error    { return f.err }
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := WriterSink{&buf}
+	s.Window(Result{
+		Start:    t0,
+		End:      at("1m"),
+		Events:   2,
+		Mentions: map[string]map[string]int{"dave": {"link": 1, "go": 2}, "ann": {"go": 1}},
+		Top:      []Count{{"#golang", 2}},
+	})
+	s.Window(Result{Start: at("1m"), End: at("2m")})
+	s.Late(Event{Time: at("30s"), User: "bob"})
+
+	want := strings.Join([]string{
+		"window 18:00:00-18:01:00: 2 tweets, top hashtags: #golang 2, topics: ann (go 1), dave (go 2, link 1)",
+		"window 18:01:00-18:02:00: 0 tweets, top hashtags: none, topics: none",
+		"late tweet of bob at 18:00:30, dropped",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
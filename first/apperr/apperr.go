@@ -0,0 +1,176 @@
+// Package apperr is an error type carrying a transport independent code,
+// a wrapped cause, key/value fields and optionally the stack it was created on.
+// It grew from MyError in first/cmd/errors.
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+type Error struct {
+	When time.Time
+	What string
+	Code Code
+	// Err is the cause, it's part of Error() but not of the transport errors.
+	Err    error
+	Fields []Field
+
+	stack []uintptr
+}
+
+// Field is a key/value pair describing an error, like the id which wasn't found.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func New(code Code, what string) *Error {
+	return &Error{When: time.Now(), What: what, Code: code}
+}
+
+func Errorf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap returns an error with the cause err, which must not be nil.
+// When code is Unknown the code of err is kept.
+func Wrap(err error, code Code, what string) *Error {
+	if code == Unknown {
+		code = CodeOf(err)
+	}
+	e := New(code, what)
+	e.Err = err
+	return e
+}
+
+// CodeOf returns the code of the first *Error in err's chain with a known code,
+// Unknown if there's none.
+func CodeOf(err error) Code {
+	var e *Error
+	for errors.As(err, &e) {
+		if e.Code != Unknown {
+			return e.Code
+		}
+		err = e.Err
+	}
+	return Unknown
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.What
+	}
+	if e.What == "" {
+		return e.Err.Error()
+	}
+	return e.What + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, &Error{Code: c}) report whether err has an *Error
+// with the code c. Other targets are compared by identity.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.What != "" || t.Err != nil || len(t.Fields) != 0 {
+		return false
+	}
+	return t.Code == e.Code
+}
+
+// With appends a field and returns e for chaining.
+func (e *Error) With(key string, value interface{}) *Error {
+	e.Fields = append(e.Fields, Field{Key: key, Value: value})
+	return e
+}
+
+// WithStack captures the stack of the caller and returns e for chaining.
+func (e *Error) WithStack() *Error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	e.stack = pcs[:n]
+	return e
+}
+
+// Stack returns the captured stack as "function file:line" lines,
+// nil if WithStack wasn't called.
+func (e *Error) Stack() []string {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	var lines []string
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		lines = append(lines, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+		if !more {
+			return lines
+		}
+	}
+}
+
+// FieldMap returns the fields of e by key, later fields win.
+func (e *Error) FieldMap() map[string]interface{} {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, len(e.Fields))
+	for _, f := range e.Fields {
+		m[f.Key] = f.Value
+	}
+	return m
+}
+
+type jsonError struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	When    *time.Time             `json:"when,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Cause   interface{}            `json:"cause,omitempty"`
+	Stack   []string               `json:"stack,omitempty"`
+}
+
+// MarshalJSON renders the whole error, including the cause and the stack,
+// for logs. Use Public for what a client may see.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	out := jsonError{
+		Code:    e.Code,
+		Message: e.What,
+		Fields:  e.FieldMap(),
+		Stack:   e.Stack(),
+	}
+	if !e.When.IsZero() {
+		out.When = &e.When
+	}
+	var cause *Error
+	switch {
+	case errors.As(e.Err, &cause):
+		out.Cause = cause
+	case e.Err != nil:
+		out.Cause = e.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// PublicError is the part of an Error sent to clients.
+type PublicError struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Public returns the code, message and fields of the first *Error in err's
+// chain, hiding causes and stacks. Errors without an *Error are Unknown.
+func Public(err error) PublicError {
+	var e *Error
+	if !errors.As(err, &e) {
+		return PublicError{Code: Unknown, Message: "unknown error"}
+	}
+	return PublicError{Code: CodeOf(err), Message: e.What, Fields: e.FieldMap()}
+}
@@ -0,0 +1,173 @@
+package apperr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+var errMissing = New(NotFound, "task not found")
+
+func TestWrap(t *testing.T) {
+	err := Wrap(io.ErrUnexpectedEOF, InvalidArgument, "can't decode task").With("id", 7)
+	wrapped := fmt.Errorf("create: %w", err)
+
+	if got := wrapped.Error(); got != "create: can't decode task: unexpected EOF" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if !errors.Is(wrapped, io.ErrUnexpectedEOF) {
+		t.Error("expected the cause to be found by errors.Is")
+	}
+	if !errors.Is(wrapped, &Error{Code: InvalidArgument}) || errors.Is(wrapped, &Error{Code: NotFound}) {
+		t.Error("expected errors.Is to match by code")
+	}
+	var e *Error
+	if !errors.As(wrapped, &e) || e != err {
+		t.Error("expected errors.As to find the error")
+	}
+	if CodeOf(wrapped) != InvalidArgument || CodeOf(io.EOF) != Unknown || CodeOf(nil) != Unknown {
+		t.Error("unexpected CodeOf")
+	}
+
+	// sentinels keep working by identity and lend their code
+	notFound := Wrap(errMissing, Unknown, "read task").With("id", 8)
+	if !errors.Is(notFound, errMissing) || CodeOf(notFound) != NotFound {
+		t.Errorf("expected the sentinel and its code, got %v", CodeOf(notFound))
+	}
+	if errors.Is(New(NotFound, "other"), errMissing) {
+		t.Error("expected sentinels compared by identity")
+	}
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range []struct {
+		code Code
+		http int
+		grpc uint32
+		name string
+	}{
+		{Unknown, 500, 2, "UNKNOWN"},
+		{InvalidArgument, 400, 3, "INVALID_ARGUMENT"},
+		{NotFound, 404, 5, "NOT_FOUND"},
+		{Unauthenticated, 401, 16, "UNAUTHENTICATED"},
+		{ResourceExhausted, 429, 8, "RESOURCE_EXHAUSTED"},
+		{Code(100), 500, 2, "UNKNOWN"},
+	} {
+		if tt.code.HTTPStatus() != tt.http || tt.code.GRPCCode() != tt.grpc || tt.code.String() != tt.name {
+			t.Errorf("%d: expected %d %d %s, got %d %d %s", tt.code, tt.http, tt.grpc, tt.name,
+				tt.code.HTTPStatus(), tt.code.GRPCCode(), tt.code)
+		}
+	}
+	for c := range codes {
+		if got := FromGRPCCode(Code(c).GRPCCode()); got != Code(c) {
+			t.Errorf("%v doesn't round trip gRPC, got %v", Code(c), got)
+		}
+	}
+	if FromGRPCCode(0) != Unknown {
+		t.Error("expected OK to be Unknown")
+	}
+}
+
+func TestStack(t *testing.T) {
+	if New(Internal, "no stack").Stack() != nil {
+		t.Error("expected no stack by default")
+	}
+	stack := New(Internal, "boom").WithStack().Stack()
+	if len(stack) == 0 || !strings.Contains(stack[0], "apperr.TestStack") || !strings.Contains(stack[0], "apperr_test.go:") {
+		t.Errorf("expected the stack to start in the test, got %v", stack)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	inner := Wrap(errors.New("disk full"), Unavailable, "can't save").WithStack()
+	err := Wrap(inner, Unknown, "create task").With("alias", "wash").With("attempt", 2)
+
+	data, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	var got struct {
+		Code    string
+		Message string
+		When    string
+		Fields  map[string]interface{}
+		Cause   struct {
+			Code  string
+			Cause string
+			Stack []string
+		}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != "UNAVAILABLE" || got.Message != "create task" || got.When == "" ||
+		got.Fields["alias"] != "wash" || got.Fields["attempt"] != 2.0 {
+		t.Errorf("unexpected JSON %s", data)
+	}
+	if got.Cause.Code != "UNAVAILABLE" || got.Cause.Cause != "disk full" || len(got.Cause.Stack) == 0 {
+		t.Errorf("unexpected cause in %s", data)
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	for _, tt := range []struct {
+		err    error
+		status int
+		body   string
+		// log is a part of the logged line
+		log string
+	}{
+		{
+			Wrap(errors.New("secret db detail"), NotFound, "task not found").With("id", 3),
+			http.StatusNotFound,
+			`{"code":"NOT_FOUND","message":"task not found","fields":{"id":3}}`,
+			`"fields":{"id":3},"cause":"secret db detail"`,
+		},
+		{
+			fmt.Errorf("handler: %w", New(InvalidArgument, "bad id")),
+			http.StatusBadRequest,
+			`{"code":"INVALID_ARGUMENT","message":"bad id"}`,
+			`400 {"code":"INVALID_ARGUMENT","message":"bad id"`,
+		},
+		{
+			fmt.Errorf("create: %w", Wrap(io.ErrUnexpectedEOF, InvalidArgument, "can't decode task").With("id", 7).WithStack()),
+			http.StatusBadRequest,
+			`{"code":"INVALID_ARGUMENT","message":"can't decode task","fields":{"id":7}}`,
+			`"cause":"unexpected EOF","stack":["github.com/awnzl/workshops/first/apperr.TestWriteHTTP`,
+		},
+		{
+			errors.New("secret db detail"),
+			http.StatusInternalServerError,
+			`{"code":"UNKNOWN","message":"unknown error"}`,
+			`500 secret db detail`,
+		},
+	} {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		rec := httptest.NewRecorder()
+		WriteHTTP(rec, tt.err)
+		log.SetOutput(os.Stderr)
+
+		if !strings.Contains(logged.String(), tt.log) {
+			t.Errorf("%v: expected %s logged, got %s", tt.err, tt.log, logged.String())
+		}
+
+		if rec.Code != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != tt.body {
+			t.Errorf("%v: expected body %s, got %s", tt.err, tt.body, got)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package apperr
+
+import "net/http"
+
+// Code classifies an error the same way on every transport.
+type Code int
+
+const (
+	Unknown Code = iota
+	Canceled
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	Unimplemented
+	Internal
+	Unavailable
+	Unauthenticated
+)
+
+type codeInfo struct {
+	name string
+	http int
+	// grpc is the google.golang.org/grpc/codes value, kept as a number so
+	// the package doesn't depend on grpc.
+	grpc uint32
+}
+
+var codes = [...]codeInfo{
+	Unknown:            {"UNKNOWN", http.StatusInternalServerError, 2},
+	Canceled:           {"CANCELED", 499, 1}, // client closed request
+	InvalidArgument:    {"INVALID_ARGUMENT", http.StatusBadRequest, 3},
+	DeadlineExceeded:   {"DEADLINE_EXCEEDED", http.StatusGatewayTimeout, 4},
+	NotFound:           {"NOT_FOUND", http.StatusNotFound, 5},
+	AlreadyExists:      {"ALREADY_EXISTS", http.StatusConflict, 6},
+	PermissionDenied:   {"PERMISSION_DENIED", http.StatusForbidden, 7},
+	ResourceExhausted:  {"RESOURCE_EXHAUSTED", http.StatusTooManyRequests, 8},
+	FailedPrecondition: {"FAILED_PRECONDITION", http.StatusBadRequest, 9},
+	Aborted:            {"ABORTED", http.StatusConflict, 10},
+	Unimplemented:      {"UNIMPLEMENTED", http.StatusNotImplemented, 12},
+	Internal:           {"INTERNAL", http.StatusInternalServerError, 13},
+	Unavailable:        {"UNAVAILABLE", http.StatusServiceUnavailable, 14},
+	Unauthenticated:    {"UNAUTHENTICATED", http.StatusUnauthorized, 16},
+}
+
+func (c Code) info() codeInfo {
+	if c < 0 || int(c) >= len(codes) {
+		return codes[Unknown]
+	}
+	return codes[c]
+}
+
+func (c Code) String() string {
+	return c.info().name
+}
+
+// HTTPStatus returns the HTTP response status for c.
+func (c Code) HTTPStatus() int {
+	return c.info().http
+}
+
+// GRPCCode returns the number of the gRPC status code for c,
+// convert it with codes.Code(c.GRPCCode()).
+func (c Code) GRPCCode() uint32 {
+	return c.info().grpc
+}
+
+// FromGRPCCode returns the Code for a gRPC status code number,
+// Unknown for OK and codes without a counterpart.
+func FromGRPCCode(grpc uint32) Code {
+	for c, info := range codes {
+		if info.grpc == grpc {
+			return Code(c)
+		}
+	}
+	return Unknown
+}
+
+func (c Code) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
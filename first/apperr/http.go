@@ -0,0 +1,28 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// WriteHTTP logs err and writes its public part as a JSON response with the
+// status of its code.
+func WriteHTTP(w http.ResponseWriter, err error) {
+	pub := Public(err)
+	var e *Error
+	if errors.As(err, &e) {
+		data, _ := json.Marshal(e)
+		log.Printf("%d %s", pub.Code.HTTPStatus(), data)
+	} else {
+		log.Printf("%d %v", pub.Code.HTTPStatus(), err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(pub.Code.HTTPStatus())
+	if err := json.NewEncoder(w).Encode(pub); err != nil {
+		log.Printf("can't write error response: %v", err)
+	}
+}
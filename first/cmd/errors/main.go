@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/awnzl/workshops/first/apperr"
+)
+
+// MyError grew into apperr.Error, which keeps When and What and adds
+// a code, a cause, fields and a stack.
+type MyError = apperr.Error
+
+func run() error {
+	_, err := os.Open("missing.txt")
+	return apperr.Wrap(err, apperr.NotFound, "it didn't work").
+		With("file", "missing.txt").
+		WithStack()
+}
+
+func main() {
+	err := run()
+	if err == nil {
+		return
+	}
+	fmt.Println(err)
+
+	var e *MyError
+	if errors.As(err, &e) {
+		fmt.Printf("at %v, code %v, HTTP %d, gRPC %d\n", e.When, e.Code, e.Code.HTTPStatus(), e.Code.GRPCCode())
+	}
+	fmt.Println("is os.ErrNotExist:", errors.Is(err, os.ErrNotExist))
+
+	data, _ := json.MarshalIndent(e, "", "  ")
+	fmt.Println(string(data))
+}
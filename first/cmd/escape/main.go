@@ -0,0 +1,75 @@
+// Command escape reports which variables the compiler moves to the heap and
+// why, and measures the allocations of the functions with testing.AllocsPerRun:
+//
+//	go run ./cmd/escape -measure 'createUser' ./cmd/user
+//	go run ./cmd/escape -all ./crawler
+//
+// The measured functions are called with zero arguments, so only the
+// functions selected by -measure run.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/awnzl/workshops/first/escape"
+)
+
+func main() {
+	measure := flag.String("measure", "", "regexp of the functions to measure with AllocsPerRun, none if empty")
+	runs := flag.Int("runs", 100, "AllocsPerRun runs")
+	all := flag.Bool("all", false, "list the decisions which don't allocate as well")
+	asJSON := flag.Bool("json", false, "write the report as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: escape [flags] package-dir | files.go...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := escape.Config{Runs: *runs}
+	if strings.HasSuffix(flag.Arg(0), ".go") {
+		cfg.Dir = filepath.Dir(flag.Arg(0))
+		for _, f := range flag.Args() {
+			if filepath.Dir(f) != cfg.Dir {
+				log.Fatalf("files must be in one directory: %s", f)
+			}
+			cfg.Files = append(cfg.Files, filepath.Base(f))
+		}
+	} else {
+		cfg.Dir = flag.Arg(0)
+	}
+	if *measure != "" {
+		re, err := regexp.Compile(*measure)
+		if err != nil {
+			log.Fatalf("invalid -measure: %v", err)
+		}
+		cfg.Measure = re
+	}
+
+	report, err := escape.Analyze(context.Background(), cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteTable(os.Stdout, *all)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/awnzl/workshops/first/crawler"
+)
+
+// Crawls the canned fetcher, or a real site given as the first argument,
+// printing the pages or exporting the result in another -format:
+//
+//	go run ./cmd/web-crawler -format dot https://go.dev/ | dot -Tsvg > crawl.svg
+func main() {
+	format := flag.String("format", "text", "output format: text, sitemap, dot, json or broken")
+	statePath := flag.String("state", "", "sqlite file to checkpoint the crawl to, a crawl interrupted with Ctrl-C resumes from it")
+	ttl := flag.Duration("ttl", 0, "fetch again resumed pages older than ttl")
+	flag.Parse()
+
+	c := crawler.Crawler{Fetcher: fetcher, Workers: 4}
+	root := "http://golang.org/"
+	if flag.NArg() > 0 {
+		c.Fetcher = &crawler.HTTPFetcher{Delay: 200 * time.Millisecond}
+		root = flag.Arg(0)
+	}
+
+	if *statePath != "" {
+		state, err := crawler.NewSQLiteState(*statePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer state.Close()
+		c.State, c.TTL = state, *ttl
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	res, err := c.Crawl(ctx, root, 4)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	switch *format {
+	case "sitemap":
+		err = res.WriteSitemap(os.Stdout)
+	case "dot":
+		err = res.WriteDOT(os.Stdout)
+	case "json":
+		err = res.WriteJSON(os.Stdout)
+	case "broken":
+		err = res.WriteBrokenLinks(os.Stdout)
+	case "text":
+		printPages(res)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func printPages(res *crawler.Result) {
+	for _, u := range res.URLs() {
+		page := res.Pages[u]
+		if page.Err != nil {
+			fmt.Println(page.Err)
+			continue
+		}
+		fmt.Printf("found: %s %q\n", page.URL, short(page.Body))
+	}
+}
+
+// short truncates real pages' bodies for printing.
+func short(body string) string {
+	if r := []rune(body); len(r) > 60 {
+		return string(r[:60]) + "..."
+	}
+	return body
+}
+
+// fakeFetcher is Fetcher that returns canned results.
+type fakeFetcher map[string]*fakeResult
+
+type fakeResult struct {
+	body string
+	urls []string
+}
+
+func (f fakeFetcher) Fetch(url string) (string, []string, error) {
+	if res, ok := f[url]; ok {
+		return res.body, res.urls, nil
+	}
+	return "", nil, fmt.Errorf("not found: %s", url)
+}
+
+// fetcher is a populated fakeFetcher.
+var fetcher = fakeFetcher{
+	"http://golang.org/": &fakeResult{
+		"The Go Programming Language",
+		[]string{
+			"http://golang.org/pkg/",
+			"http://golang.org/cmd/",
+		},
+	},
+	"http://golang.org/pkg/": &fakeResult{
+		"Packages",
+		[]string{
+			"http://golang.org/",
+			"http://golang.org/cmd/",
+			"http://golang.org/pkg/fmt/",
+			"http://golang.org/pkg/os/",
+		},
+	},
+	"http://golang.org/pkg/fmt/": &fakeResult{
+		"Package fmt",
+		[]string{
+			"http://golang.org/",
+			"http://golang.org/pkg/",
+		},
+	},
+	"http://golang.org/pkg/os/": &fakeResult{
+		"Package os",
+		[]string{
+			"http://golang.org/",
+			"http://golang.org/pkg/",
+		},
+	},
+}
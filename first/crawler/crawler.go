@@ -0,0 +1,274 @@
+// Package crawler crawls pages in parallel with a bounded number of workers,
+// fetching every URL once.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Fetcher interface {
+	// Fetch returns the body of URL and
+	// a slice of URLs found on that page.
+	Fetch(url string) (body string, urls []string, err error)
+}
+
+// ContextFetcher is a Fetcher which can abort a fetch when ctx is done.
+// Crawler prefers FetchContext when the fetcher implements it.
+type ContextFetcher interface {
+	Fetcher
+	FetchContext(ctx context.Context, url string) (body string, urls []string, err error)
+}
+
+const DefaultWorkers = 4
+
+// Page is a node of the crawl graph.
+type Page struct {
+	URL string
+	// Depth is the distance from the root page.
+	Depth int
+	Body  string
+	Links []string
+	// Err is the fetch error, such pages have no body and links.
+	Err     error
+	Fetched time.Time
+}
+
+// Result is the crawl graph, pages are linked by URLs.
+type Result struct {
+	Root  string
+	Pages map[string]*Page
+}
+
+// URLs returns the crawled URLs ordered by depth, then alphabetically.
+func (r *Result) URLs() []string {
+	urls := make([]string, 0, len(r.Pages))
+	for u := range r.Pages {
+		urls = append(urls, u)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		pi, pj := r.Pages[urls[i]], r.Pages[urls[j]]
+		if pi.Depth != pj.Depth {
+			return pi.Depth < pj.Depth
+		}
+		return pi.URL < pj.URL
+	})
+	return urls
+}
+
+// Visited is a set of URLs safe for concurrent use.
+type Visited struct {
+	mu   sync.Mutex
+	urls map[string]struct{}
+}
+
+func NewVisited() *Visited {
+	return &Visited{urls: make(map[string]struct{})}
+}
+
+// Add marks url as visited and reports whether it wasn't visited before.
+func (v *Visited) Add(url string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.urls[url]; ok {
+		return false
+	}
+	v.urls[url] = struct{}{}
+	return true
+}
+
+func (v *Visited) Has(url string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, ok := v.urls[url]
+	return ok
+}
+
+func (v *Visited) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return len(v.urls)
+}
+
+type Crawler struct {
+	Fetcher Fetcher
+	// Workers bounds the number of parallel fetches, DefaultWorkers if zero.
+	Workers int
+	// State checkpoints the crawl so it can resume after a restart,
+	// the crawl is kept in memory only if nil.
+	State State
+	// TTL makes a resumed crawl fetch again pages fetched more than TTL ago,
+	// fetched pages never expire if zero.
+	TTL time.Duration
+}
+
+// Task is a URL waiting in the frontier.
+type Task struct {
+	URL   string
+	Depth int
+}
+
+// Crawl fetches pages starting with root, to a maximum of depth, like the
+// Tour of Go exercise: depth 1 fetches only the root. When ctx is done it
+// returns the pages fetched so far along with ctx.Err().
+//
+// With a State, Crawl continues the crawl saved there: saved pages are
+// part of the result and only the saved frontier is fetched.
+func (c *Crawler) Crawl(ctx context.Context, root string, depth int) (*Result, error) {
+	res := &Result{Root: root, Pages: make(map[string]*Page)}
+	if depth <= 0 {
+		return res, nil
+	}
+
+	state := c.State
+	if state == nil {
+		state = NewMemoryState()
+	}
+	frontier, visited, err := c.resume(state, res, root, depth)
+	if err != nil {
+		return res, err
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tasks := make(chan Task)
+	pages := make(chan *Page)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				p := c.fetch(ctx, t)
+				select {
+				case pages <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	stop := func() {
+		cancel()
+		close(tasks)
+		wg.Wait()
+	}
+
+	inFlight := 0
+
+	for len(frontier) > 0 || inFlight > 0 {
+		// sending on a nil channel blocks, so nothing is dispatched while the frontier is empty
+		var next chan Task
+		if len(frontier) > 0 {
+			next = tasks
+		}
+
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			stop()
+			return res, err
+		case next <- frontierHead(frontier):
+			frontier = frontier[1:]
+			inFlight++
+		case p := <-pages:
+			inFlight--
+			res.Pages[p.URL] = p
+			queued := c.expand(p, depth, visited)
+			// the page and its links are saved together, so a restart
+			// neither loses the links nor fetches the page again
+			if err := state.Save(p, queued); err != nil {
+				stop()
+				return res, fmt.Errorf("save %s: %w", p.URL, err)
+			}
+			frontier = append(frontier, queued...)
+		}
+	}
+
+	stop()
+	return res, nil
+}
+
+// resume loads the saved crawl into res and returns the frontier to fetch,
+// along with the visited URLs: the saved frontier, the stale pages and the
+// unvisited links of pages above depth. A new crawl starts with root.
+func (c *Crawler) resume(state State, res *Result, root string, depth int) ([]Task, *Visited, error) {
+	saved, queued, err := state.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load state: %w", err)
+	}
+
+	visited := NewVisited()
+	var frontier, added []Task
+	for _, t := range queued {
+		if t.Depth < depth && visited.Add(t.URL) {
+			frontier = append(frontier, t)
+		}
+	}
+
+	now := time.Now()
+	for _, p := range saved {
+		// stale pages stay in the result until fetched again
+		res.Pages[p.URL] = p
+		if visited.Add(p.URL) && c.TTL > 0 && now.Sub(p.Fetched) > c.TTL {
+			added = append(added, Task{URL: p.URL, Depth: p.Depth})
+		}
+	}
+	if visited.Add(root) {
+		added = append(added, Task{URL: root})
+	}
+	for _, p := range saved {
+		added = append(added, c.expand(p, depth, visited)...)
+	}
+
+	if len(added) > 0 {
+		if err := state.Queue(added); err != nil {
+			return nil, nil, fmt.Errorf("queue: %w", err)
+		}
+	}
+	return append(frontier, added...), visited, nil
+}
+
+// expand returns the tasks for the links of p not visited yet.
+func (c *Crawler) expand(p *Page, depth int, visited *Visited) []Task {
+	if p.Depth+1 >= depth {
+		return nil
+	}
+	var tasks []Task
+	for _, u := range p.Links {
+		if visited.Add(u) {
+			tasks = append(tasks, Task{URL: u, Depth: p.Depth + 1})
+		}
+	}
+	return tasks
+}
+
+func frontierHead(frontier []Task) Task {
+	if len(frontier) == 0 {
+		return Task{}
+	}
+	return frontier[0]
+}
+
+func (c *Crawler) fetch(ctx context.Context, t Task) *Page {
+	p := &Page{URL: t.URL, Depth: t.Depth}
+	if cf, ok := c.Fetcher.(ContextFetcher); ok {
+		p.Body, p.Links, p.Err = cf.FetchContext(ctx, t.URL)
+	} else {
+		p.Body, p.Links, p.Err = c.Fetcher.Fetch(t.URL)
+	}
+	p.Fetched = time.Now()
+	return p
+}
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingFetcher serves a canned site, counting fetches and the parallel ones.
+type countingFetcher struct {
+	site  map[string][]string
+	delay time.Duration
+
+	mu          sync.Mutex
+	fetches     map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func newCountingFetcher(delay time.Duration) *countingFetcher {
+	return &countingFetcher{
+		site: map[string][]string{
+			"http://golang.org/":         {"http://golang.org/pkg/", "http://golang.org/cmd/"},
+			"http://golang.org/pkg/":     {"http://golang.org/", "http://golang.org/cmd/", "http://golang.org/pkg/fmt/", "http://golang.org/pkg/os/"},
+			"http://golang.org/pkg/fmt/": {"http://golang.org/", "http://golang.org/pkg/"},
+			"http://golang.org/pkg/os/":  {"http://golang.org/", "http://golang.org/pkg/"},
+		},
+		delay:   delay,
+		fetches: make(map[string]int),
+	}
+}
+
+func (f *countingFetcher) Fetch(url string) (string, []string, error) {
+	f.mu.Lock()
+	f.fetches[url]++
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	if urls, ok := f.site[url]; ok {
+		return "body of " + url, urls, nil
+	}
+	return "", nil, fmt.Errorf("not found: %s", url)
+}
+
+func TestCrawl(t *testing.T) {
+	f := newCountingFetcher(10 * time.Millisecond)
+	c := Crawler{Fetcher: f, Workers: 2}
+
+	res, err := c.Crawl(context.Background(), "http://golang.org/", 4)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	want := []string{
+		"http://golang.org/",
+		"http://golang.org/cmd/",
+		"http://golang.org/pkg/",
+		"http://golang.org/pkg/fmt/",
+		"http://golang.org/pkg/os/",
+	}
+	if got := res.URLs(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for u, n := range f.fetches {
+		if n != 1 {
+			t.Errorf("%s fetched %d times", u, n)
+		}
+	}
+	if f.maxInFlight > 2 {
+		t.Errorf("expected at most 2 parallel fetches, got %d", f.maxInFlight)
+	}
+
+	if p := res.Pages["http://golang.org/cmd/"]; p.Err == nil || p.Depth != 1 {
+		t.Errorf("expected not found error at depth 1, got %+v", p)
+	}
+	if p := res.Pages["http://golang.org/pkg/fmt/"]; p.Body != "body of http://golang.org/pkg/fmt/" || len(p.Links) != 2 || p.Depth != 2 {
+		t.Errorf("unexpected page %+v", p)
+	}
+}
+
+func TestCrawlDepth(t *testing.T) {
+	for depth, pages := range map[int]int{0: 0, 1: 1, 2: 3, 3: 5} {
+		res, err := (&Crawler{Fetcher: newCountingFetcher(0)}).Crawl(context.Background(), "http://golang.org/", depth)
+		if err != nil {
+			t.Fatalf("Crawl: %v", err)
+		}
+		if len(res.Pages) != pages {
+			t.Errorf("depth %d: expected %d pages, got %v", depth, pages, res.URLs())
+		}
+	}
+}
+
+func TestCrawlCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Millisecond)
+	defer cancel()
+
+	c := Crawler{Fetcher: newCountingFetcher(10 * time.Millisecond), Workers: 1}
+	res, err := c.Crawl(ctx, "http://golang.org/", 4)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if len(res.Pages) == 0 || len(res.Pages) == 5 {
+		t.Errorf("expected a partial result, got %v", res.URLs())
+	}
+}
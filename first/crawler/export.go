@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// BrokenLink is a page that failed to fetch and the pages linking to it.
+type BrokenLink struct {
+	URL       string   `json:"url"`
+	Err       string   `json:"error"`
+	Referrers []string `json:"referrers"`
+}
+
+// BrokenLinks returns the pages with fetch errors in URLs order, each with
+// the sorted URLs of the pages referencing it.
+func (r *Result) BrokenLinks() []BrokenLink {
+	referrers := make(map[string][]string)
+	for _, p := range r.Pages {
+		for _, u := range p.Links {
+			if to, ok := r.Pages[u]; ok && to.Err != nil {
+				referrers[u] = append(referrers[u], p.URL)
+			}
+		}
+	}
+
+	var broken []BrokenLink
+	for _, u := range r.URLs() {
+		p := r.Pages[u]
+		if p.Err == nil {
+			continue
+		}
+		refs := referrers[u]
+		sort.Strings(refs)
+		broken = append(broken, BrokenLink{URL: u, Err: p.Err.Error(), Referrers: refs})
+	}
+	return broken
+}
+
+// WriteBrokenLinks writes a plain text report of the broken links.
+func (r *Result) WriteBrokenLinks(w io.Writer) error {
+	ew := &errWriter{w: w}
+	for _, b := range r.BrokenLinks() {
+		ew.printf("%s: %s\n", b.URL, b.Err)
+		for _, ref := range b.Referrers {
+			ew.printf("\treferenced by %s\n", ref)
+		}
+	}
+	return ew.err
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// WriteSitemap writes the fetched pages as a sitemaps.org sitemap.xml,
+// pages with errors are left out.
+func (r *Result) WriteSitemap(w io.Writer) error {
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, u := range r.URLs() {
+		if r.Pages[u].Err == nil {
+			set.URLs = append(set.URLs, sitemapURL{Loc: u})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(set); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteDOT writes the link graph of the crawled pages in Graphviz DOT,
+// broken pages are drawn red.
+func (r *Result) WriteDOT(w io.Writer) error {
+	urls := r.URLs()
+	ew := &errWriter{w: w}
+
+	ew.printf("digraph crawl {\n")
+	for _, u := range urls {
+		if r.Pages[u].Err != nil {
+			ew.printf("\t%q [color=red];\n", u)
+		} else {
+			ew.printf("\t%q;\n", u)
+		}
+	}
+	for _, u := range urls {
+		// links to pages beyond the crawl depth aren't in the graph
+		for _, l := range r.Pages[u].Links {
+			if _, ok := r.Pages[l]; ok {
+				ew.printf("\t%q -> %q;\n", u, l)
+			}
+		}
+	}
+	ew.printf("}\n")
+	return ew.err
+}
+
+type jsonResult struct {
+	Root  string     `json:"root"`
+	Pages []jsonPage `json:"pages"`
+}
+
+type jsonPage struct {
+	URL   string   `json:"url"`
+	Depth int      `json:"depth"`
+	Links []string `json:"links,omitempty"`
+	Err   string   `json:"error,omitempty"`
+}
+
+// WriteJSON writes the crawl graph as JSON, without page bodies.
+func (r *Result) WriteJSON(w io.Writer) error {
+	out := jsonResult{Root: r.Root, Pages: []jsonPage{}}
+	for _, u := range r.URLs() {
+		p := r.Pages[u]
+		jp := jsonPage{URL: p.URL, Depth: p.Depth, Links: p.Links}
+		if p.Err != nil {
+			jp.Err = p.Err.Error()
+		}
+		out.Pages = append(out.Pages, jp)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// errWriter keeps the first write error, so a sequence of writes is checked once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func crawlGolang(t *testing.T, depth int) *Result {
+	t.Helper()
+	res, err := (&Crawler{Fetcher: newCountingFetcher(0)}).Crawl(context.Background(), "http://golang.org/", depth)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	return res
+}
+
+func TestBrokenLinks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := crawlGolang(t, 4).WriteBrokenLinks(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `http://golang.org/cmd/: not found: http://golang.org/cmd/
+	referenced by http://golang.org/
+	referenced by http://golang.org/pkg/
+`
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func TestWriteSitemap(t *testing.T) {
+	var buf bytes.Buffer
+	if err := crawlGolang(t, 4).WriteSitemap(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://golang.org/</loc>
+  </url>
+  <url>
+    <loc>http://golang.org/pkg/</loc>
+  </url>
+  <url>
+    <loc>http://golang.org/pkg/fmt/</loc>
+  </url>
+  <url>
+    <loc>http://golang.org/pkg/os/</loc>
+  </url>
+</urlset>
+`
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := crawlGolang(t, 2).WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph crawl {
+	"http://golang.org/";
+	"http://golang.org/cmd/" [color=red];
+	"http://golang.org/pkg/";
+	"http://golang.org/" -> "http://golang.org/pkg/";
+	"http://golang.org/" -> "http://golang.org/cmd/";
+	"http://golang.org/pkg/" -> "http://golang.org/";
+	"http://golang.org/pkg/" -> "http://golang.org/cmd/";
+}
+`
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := crawlGolang(t, 2).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "body of") {
+		t.Errorf("expected no bodies, got %s", buf.String())
+	}
+
+	var got jsonResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Root != "http://golang.org/" || len(got.Pages) != 3 {
+		t.Fatalf("unexpected result %+v", got)
+	}
+	if p := got.Pages[1]; p.URL != "http://golang.org/cmd/" || p.Depth != 1 || p.Err == "" || p.Links != nil {
+		t.Errorf("unexpected broken page %+v", p)
+	}
+	if p := got.Pages[2]; p.URL != "http://golang.org/pkg/" || len(p.Links) != 4 || p.Err != "" {
+		t.Errorf("unexpected page %+v", p)
+	}
+}
@@ -0,0 +1,223 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultUserAgent   = "workshops-crawler/1.0"
+	DefaultMaxBodySize = 1 << 20
+)
+
+var (
+	ErrDisallowed   = errors.New("disallowed by robots.txt")
+	ErrBodyTooLarge = errors.New("body too large")
+)
+
+// HTTPFetcher fetches pages over HTTP and extracts links from HTML bodies.
+// It honors robots.txt and waits Delay between requests to the same host.
+// The zero value is ready to use.
+type HTTPFetcher struct {
+	// Client is http.DefaultClient if nil.
+	Client *http.Client
+	// UserAgent is sent with requests and picks the robots.txt group,
+	// DefaultUserAgent if empty.
+	UserAgent string
+	// MaxBodySize limits the bytes read from a page, DefaultMaxBodySize if zero.
+	MaxBodySize int64
+	// Delay is the minimum interval between requests to a host.
+	Delay time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// host is the per-host state: the robots.txt rules and the rate limit.
+type host struct {
+	// robotsMu guards robots, nil until a robots.txt is fetched
+	robotsMu sync.Mutex
+	robots   *Robots
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (f *HTTPFetcher) Fetch(url string) (string, []string, error) {
+	return f.FetchContext(context.Background(), url)
+}
+
+func (f *HTTPFetcher) FetchContext(ctx context.Context, rawURL string) (string, []string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", nil, fmt.Errorf("unsupported scheme: %s", rawURL)
+	}
+
+	h := f.host(u.Host)
+	robots, err := f.robots(ctx, h, u)
+	if err != nil {
+		return "", nil, err
+	}
+	if !robots.Allowed(u.EscapedPath()) {
+		return "", nil, fmt.Errorf("%w: %s", ErrDisallowed, rawURL)
+	}
+
+	resp, err := f.get(ctx, h, u.String())
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+
+	max := f.MaxBodySize
+	if max <= 0 {
+		max = DefaultMaxBodySize
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if int64(len(body)) > max {
+		return "", nil, fmt.Errorf("%w: %s", ErrBodyTooLarge, rawURL)
+	}
+
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != "text/html" {
+		return string(body), nil, nil
+	}
+	// links are relative to the final URL after redirects
+	links, err := ExtractLinks(resp.Request.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(body), links, nil
+}
+
+func (f *HTTPFetcher) host(name string) *host {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.hosts == nil {
+		f.hosts = make(map[string]*host)
+	}
+	h, ok := f.hosts[name]
+	if !ok {
+		h = &host{}
+		f.hosts[name] = h
+	}
+	return h
+}
+
+// robots returns the robots.txt rules of u's host, fetched by the first
+// request to it. The rules of an unreachable robots.txt aren't kept, the
+// next request fetches it again.
+func (f *HTTPFetcher) robots(ctx context.Context, h *host, u *url.URL) (*Robots, error) {
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+
+	if h.robots != nil {
+		return h.robots, nil
+	}
+	r, keep, err := f.fetchRobots(ctx, h, u)
+	if err != nil {
+		return nil, err
+	}
+	if keep {
+		h.robots = r
+	}
+	return r, nil
+}
+
+// disallowAll is the rules of an unreachable robots.txt.
+var disallowAll = &Robots{rules: []robotsRule{{path: "/"}}}
+
+// fetchRobots fetches the robots.txt rules of u's host. As RFC 9309 says, a
+// missing robots.txt, answering 4xx, allows everything, and an unreachable
+// one, answering 5xx or 429, disallows everything. keep reports whether the
+// rules hold for the next requests, err is a failed request or read.
+func (f *HTTPFetcher) fetchRobots(ctx context.Context, h *host, u *url.URL) (r *Robots, keep bool, err error) {
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := f.get(ctx, h, robotsURL.String())
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return disallowAll, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return &Robots{}, true, nil
+	}
+	r, err = ParseRobots(io.LimitReader(resp.Body, DefaultMaxBodySize), f.userAgent())
+	if err != nil {
+		return nil, false, err
+	}
+	return r, true, nil
+}
+
+// get sends a GET request once the host's rate limit allows it.
+func (f *HTTPFetcher) get(ctx context.Context, h *host, url string) (*http.Response, error) {
+	if err := f.wait(ctx, h); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent())
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// wait reserves the host's next request slot and sleeps until it comes.
+func (f *HTTPFetcher) wait(ctx context.Context, h *host) error {
+	h.mu.Lock()
+	now := time.Now()
+	at := h.next
+	if at.Before(now) {
+		at = now
+	}
+	h.next = at.Add(f.Delay)
+	h.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *HTTPFetcher) userAgent() string {
+	if f.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return f.UserAgent
+}
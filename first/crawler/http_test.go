@@ -0,0 +1,318 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// site is an httptest server fixture recording the requested paths.
+type site struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []string
+	agents   map[string]bool
+}
+
+func newSite(t *testing.T) *site {
+	s := &site{agents: make(map[string]bool)}
+
+	mux := http.NewServeMux()
+	page := func(path, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, body)
+		})
+	}
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\nAllow: /private/open.html\n")
+	})
+	page("/", `<html><body>
+		<a href="/docs/">Docs</a>
+		<a href="about.html#team">About</a>
+		<a href="/about.html">About again</a>
+		<a href="mailto:gopher@example.com">Mail</a>
+		<a href="/private/secret.html">Secret</a>
+		<a href="/private/open.html">Open</a>
+		<a href="/missing.html">Missing</a>
+		<a href="/big.html">Big</a>
+		<a href="/data.txt">Data</a>
+	</body></html>`)
+	page("/about.html", `<a href="/">Home</a>`)
+	page("/docs/", `<base href="/docs/v2/"><a href="intro.html">Intro</a> <a href="../">Up</a>`)
+	page("/docs/v2/intro.html", `<a href="http://example.invalid/ext">External</a>`)
+	page("/private/secret.html", `secret`)
+	page("/private/open.html", `open`)
+	page("/big.html", strings.Repeat("x", 2048))
+	mux.HandleFunc("/data.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, `<a href="/not-a-link">`)
+	})
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		s.agents[r.UserAgent()] = true
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *site) requested(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, p := range s.requests {
+		if p == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	s := newSite(t)
+	f := &HTTPFetcher{MaxBodySize: 1024, UserAgent: "test-agent"}
+
+	body, links, err := f.Fetch(s.URL + "/")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !strings.Contains(body, "Docs") {
+		t.Errorf("unexpected body %q", body)
+	}
+	want := []string{
+		s.URL + "/docs/",
+		s.URL + "/about.html",
+		s.URL + "/private/secret.html",
+		s.URL + "/private/open.html",
+		s.URL + "/missing.html",
+		s.URL + "/big.html",
+		s.URL + "/data.txt",
+	}
+	if fmt.Sprint(links) != fmt.Sprint(want) {
+		t.Errorf("expected links %v, got %v", want, links)
+	}
+
+	_, links, err = f.Fetch(s.URL + "/docs/")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if want := []string{s.URL + "/docs/v2/intro.html", s.URL + "/docs/"}; fmt.Sprint(links) != fmt.Sprint(want) {
+		t.Errorf("expected links resolved against <base>, %v, got %v", want, links)
+	}
+
+	if _, _, err := f.Fetch(s.URL + "/private/secret.html"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected ErrDisallowed, got %v", err)
+	}
+	if s.requested("/private/secret.html") != 0 {
+		t.Error("disallowed page was requested")
+	}
+	if _, _, err := f.Fetch(s.URL + "/private/open.html"); err != nil {
+		t.Errorf("expected allowed page, got %v", err)
+	}
+
+	if _, _, err := f.Fetch(s.URL + "/big.html"); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected ErrBodyTooLarge, got %v", err)
+	}
+	if _, _, err := f.Fetch(s.URL + "/missing.html"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+
+	body, links, err = f.Fetch(s.URL + "/data.txt")
+	if err != nil || links != nil || body == "" {
+		t.Errorf("expected a plain text body without links, got %q %v %v", body, links, err)
+	}
+
+	if n := s.requested("/robots.txt"); n != 1 {
+		t.Errorf("expected robots.txt fetched once, got %d", n)
+	}
+	if !s.agents["test-agent"] || len(s.agents) != 1 {
+		t.Errorf("expected only test-agent requests, got %v", s.agents)
+	}
+}
+
+func TestHTTPFetcherDelay(t *testing.T) {
+	s := newSite(t)
+	f := &HTTPFetcher{Delay: 20 * time.Millisecond}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, _, err := f.Fetch(s.URL + "/about.html"); err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+	}
+	// robots.txt and three pages, three intervals between four requests
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("expected requests spaced by Delay, took %v", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := f.FetchContext(ctx, s.URL+"/about.html"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Canceled, got %v", err)
+	}
+}
+
+func TestCrawlHTTP(t *testing.T) {
+	s := newSite(t)
+	c := Crawler{Fetcher: &HTTPFetcher{MaxBodySize: 1024}}
+
+	res, err := c.Crawl(context.Background(), s.URL+"/", 3)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	for path, ok := range map[string]bool{
+		"/":                    true,
+		"/about.html":          true,
+		"/docs/":               true,
+		"/docs/v2/intro.html":  true,
+		"/private/open.html":   true,
+		"/data.txt":            true,
+		"/private/secret.html": false,
+		"/missing.html":        false,
+		"/big.html":            false,
+	} {
+		p := res.Pages[s.URL+path]
+		if p == nil {
+			t.Errorf("%s not crawled", path)
+			continue
+		}
+		if (p.Err == nil) != ok {
+			t.Errorf("%s: unexpected error %v", path, p.Err)
+		}
+	}
+	if p := res.Pages["http://example.invalid/ext"]; p != nil {
+		t.Errorf("expected depth limit to stop at intro.html, got %+v", p)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	const txt = `# comment
+User-agent: other
+Disallow: /
+
+User-agent: mybot
+User-agent: friend
+Disallow: /tmp/
+Allow: /tmp/public
+
+User-agent: *
+Disallow: /admin
+Disallow:
+`
+	for _, tt := range []struct {
+		agent, path string
+		allowed     bool
+	}{
+		{"MyBot/2.0", "/", true},
+		{"MyBot/2.0", "/tmp/x", false},
+		{"MyBot/2.0", "/tmp/public/x", true},
+		{"MyBot/2.0", "/admin", true},
+		{"friend", "/tmp/", false},
+		{"anyone", "/admin/users", false},
+		{"anyone", "/tmp/x", true},
+		{"other", "/index.html", false},
+	} {
+		r, err := ParseRobots(strings.NewReader(txt), tt.agent)
+		if err != nil {
+			t.Fatalf("ParseRobots: %v", err)
+		}
+		if got := r.Allowed(tt.path); got != tt.allowed {
+			t.Errorf("%s %s: expected allowed %v, got %v", tt.agent, tt.path, tt.allowed, got)
+		}
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	base, _ := url.Parse("http://example.com/a/b.html")
+	links, err := ExtractLinks(base, strings.NewReader(`
+		<a href="c.html">c</a>
+		<A HREF=" /d ">d</A>
+		<a name="no-href">x</a>
+		<a href="javascript:void(0)">js</a>
+		<a href="https://other.org/e?q=1#frag">e</a>
+		<a href="c.html#again">c again</a>
+		<link href="/style.css">`))
+	if err != nil {
+		t.Fatalf("ExtractLinks: %v", err)
+	}
+	want := []string{"http://example.com/a/c.html", "http://example.com/d", "https://other.org/e?q=1"}
+	if fmt.Sprint(links) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, links)
+	}
+}
+
+func TestHTTPFetcherRobotsUnreachable(t *testing.T) {
+	var mu sync.Mutex
+	status, robots, pages := http.StatusServiceUnavailable, 0, 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/robots.txt" {
+			pages++
+			return
+		}
+		robots++
+		w.WriteHeader(status)
+	}))
+	defer s.Close()
+	f := &HTTPFetcher{}
+
+	for _, code := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		mu.Lock()
+		status = code
+		mu.Unlock()
+		if _, _, err := f.Fetch(s.URL + "/"); !errors.Is(err, ErrDisallowed) {
+			t.Errorf("%d: expected ErrDisallowed, got %v", code, err)
+		}
+	}
+
+	// a missing robots.txt allows everything, for the next requests too
+	mu.Lock()
+	status = http.StatusNotFound
+	mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if _, _, err := f.Fetch(s.URL + "/"); err != nil {
+			t.Errorf("expected allowed page, got %v", err)
+		}
+	}
+	if robots != 3 || pages != 2 {
+		t.Errorf("expected robots.txt fetched 3 times and 2 pages, got %d and %d", robots, pages)
+	}
+}
+
+func TestHTTPFetcherRobotsCanceled(t *testing.T) {
+	s := newSite(t)
+	f := &HTTPFetcher{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := f.FetchContext(ctx, s.URL+"/private/secret.html"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Canceled, got %v", err)
+	}
+
+	// the canceled request left no rules behind
+	if _, _, err := f.Fetch(s.URL + "/private/secret.html"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected ErrDisallowed, got %v", err)
+	}
+	if n := s.requested("/robots.txt"); n != 1 {
+		t.Errorf("expected robots.txt fetched once, got %d", n)
+	}
+}
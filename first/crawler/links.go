@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ExtractLinks returns the absolute http(s) URLs of the anchors in an HTML
+// document, resolved against base or the document's <base href>. Fragments
+// are dropped and every link is returned once, in document order.
+func ExtractLinks(base *url.URL, r io.Reader) ([]string, error) {
+	var links []string
+	seen := make(map[string]bool)
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return links, nil
+			}
+			return links, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !hasAttr {
+				continue
+			}
+			tag := string(name)
+			if tag != "a" && tag != "base" {
+				continue
+			}
+
+			href, ok := attr(z, "href")
+			if !ok {
+				continue
+			}
+			u, err := base.Parse(strings.TrimSpace(href))
+			if err != nil {
+				continue
+			}
+
+			if tag == "base" {
+				base = u
+				continue
+			}
+			if u.Scheme != "http" && u.Scheme != "https" {
+				continue
+			}
+			u.Fragment = ""
+			if link := u.String(); !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+}
+
+func attr(z *html.Tokenizer, name string) (string, bool) {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == name {
+			return string(val), true
+		}
+		if !more {
+			return "", false
+		}
+	}
+}
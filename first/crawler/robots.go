@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Robots holds the rules of a robots.txt that apply to one user agent.
+type Robots struct {
+	rules []robotsRule
+}
+
+type robotsRule struct {
+	path  string
+	allow bool
+}
+
+// ParseRobots parses a robots.txt, keeping the group for userAgent, or the
+// "*" group if there's no group naming it.
+func ParseRobots(r io.Reader, userAgent string) (*Robots, error) {
+	userAgent = strings.ToLower(userAgent)
+
+	var (
+		own, wildcard   []robotsRule
+		hasOwn          bool
+		agents          []string
+		inRules         bool
+		matchesOwn      bool
+		matchesWildcard bool
+	)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		val := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			// a user-agent line after rules starts a new group
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(val))
+			matchesOwn, matchesWildcard = false, false
+			for _, a := range agents {
+				if a == "*" {
+					matchesWildcard = true
+				} else if userAgent != "" && strings.Contains(userAgent, a) {
+					matchesOwn = true
+				}
+			}
+			hasOwn = hasOwn || matchesOwn
+		case "allow", "disallow":
+			inRules = true
+			// an empty Disallow allows everything
+			if val == "" {
+				continue
+			}
+			rule := robotsRule{path: val, allow: key == "allow"}
+			if matchesOwn {
+				own = append(own, rule)
+			}
+			if matchesWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if hasOwn {
+		return &Robots{rules: own}, nil
+	}
+	return &Robots{rules: wildcard}, nil
+}
+
+// Allowed reports whether path may be fetched. The longest matching rule
+// wins and Allow wins a tie, paths matching no rule are allowed.
+func (r *Robots) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	best, allowed := -1, true
+	for _, rule := range r.rules {
+		if !strings.HasPrefix(path, rule.path) {
+			continue
+		}
+		if n := len(rule.path); n > best || n == best && rule.allow {
+			best, allowed = n, rule.allow
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const createState = `
+CREATE TABLE IF NOT EXISTS pages (
+	url     TEXT    PRIMARY KEY,
+	depth   INTEGER NOT NULL,
+	body    TEXT    NOT NULL,
+	links   TEXT    NOT NULL,
+	error   TEXT,
+	fetched INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS frontier (
+	seq   INTEGER PRIMARY KEY AUTOINCREMENT,
+	url   TEXT    NOT NULL UNIQUE,
+	depth INTEGER NOT NULL
+);`
+
+// SQLiteState keeps the crawl in a sqlite database, so a crawl interrupted
+// by a restart resumes from its file.
+type SQLiteState struct {
+	db *sql.DB
+}
+
+func NewSQLiteState(dsn string) (*SQLiteState, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// sqlite allows a single writer, in-memory databases also live per connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(createState); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &SQLiteState{db: db}, nil
+}
+
+func (s *SQLiteState) Load() ([]*Page, []Task, error) {
+	rows, err := s.db.Query("SELECT url, depth, body, links, error, fetched FROM pages ORDER BY depth, url")
+	if err != nil {
+		return nil, nil, fmt.Errorf("select pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []*Page
+	for rows.Next() {
+		var (
+			p       Page
+			links   []byte
+			errText sql.NullString
+			fetched int64
+		)
+		if err := rows.Scan(&p.URL, &p.Depth, &p.Body, &links, &errText, &fetched); err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(links, &p.Links); err != nil {
+			return nil, nil, fmt.Errorf("unmarshal links of %s: %w", p.URL, err)
+		}
+		if errText.Valid {
+			p.Err = errors.New(errText.String)
+		}
+		p.Fetched = time.Unix(0, fetched)
+		pages = append(pages, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = s.db.Query("SELECT url, depth FROM frontier ORDER BY seq")
+	if err != nil {
+		return nil, nil, fmt.Errorf("select frontier: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.URL, &t.Depth); err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return pages, tasks, rows.Err()
+}
+
+func (s *SQLiteState) Queue(tasks []Task) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := queue(tx, tasks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteState) Save(p *Page, queued []Task) error {
+	links, err := json.Marshal(p.Links)
+	if err != nil {
+		return fmt.Errorf("marshal links: %w", err)
+	}
+	var errText sql.NullString
+	if p.Err != nil {
+		errText = sql.NullString{String: p.Err.Error(), Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT OR REPLACE INTO pages (url, depth, body, links, error, fetched) VALUES (?, ?, ?, ?, ?, ?)",
+		p.URL, p.Depth, p.Body, links, errText, p.Fetched.UnixNano()); err != nil {
+		return fmt.Errorf("insert page: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM frontier WHERE url = ?", p.URL); err != nil {
+		return fmt.Errorf("delete from frontier: %w", err)
+	}
+	if err := queue(tx, queued); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func queue(tx *sql.Tx, tasks []Task) error {
+	for _, t := range tasks {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO frontier (url, depth) VALUES (?, ?)", t.URL, t.Depth); err != nil {
+			return fmt.Errorf("insert into frontier: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteState) Close() error {
+	return s.db.Close()
+}
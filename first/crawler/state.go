@@ -0,0 +1,60 @@
+package crawler
+
+import "sync"
+
+// State is where Crawler checkpoints a crawl: the fetched pages and the
+// frontier of URLs waiting to be fetched.
+type State interface {
+	// Load returns the saved pages and the frontier in queue order.
+	Load() ([]*Page, []Task, error)
+	// Queue appends tasks to the frontier.
+	Queue(tasks []Task) error
+	// Save stores a fetched page, replacing an earlier fetch of its URL,
+	// removes it from the frontier and queues the tasks for its links.
+	Save(p *Page, queued []Task) error
+}
+
+// MemoryState keeps the crawl in memory, it's lost with the process.
+type MemoryState struct {
+	mu    sync.Mutex
+	pages map[string]*Page
+	queue []Task
+}
+
+func NewMemoryState() *MemoryState {
+	return &MemoryState{pages: make(map[string]*Page)}
+}
+
+func (s *MemoryState) Load() ([]*Page, []Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pages := make([]*Page, 0, len(s.pages))
+	for _, p := range s.pages {
+		pages = append(pages, p)
+	}
+	return pages, append([]Task(nil), s.queue...), nil
+}
+
+func (s *MemoryState) Queue(tasks []Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queue = append(s.queue, tasks...)
+	return nil
+}
+
+func (s *MemoryState) Save(p *Page, queued []Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pages[p.URL] = p
+	for i, t := range s.queue {
+		if t.URL == p.URL {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			break
+		}
+	}
+	s.queue = append(s.queue, queued...)
+	return nil
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCrawlResume(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "crawl.db")
+
+	state, err := NewSQLiteState(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+	c := Crawler{Fetcher: newCountingFetcher(10 * time.Millisecond), Workers: 1, State: state}
+	first, err := c.Crawl(ctx, "http://golang.org/", 4)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if len(first.Pages) == 0 || len(first.Pages) == 5 {
+		t.Fatalf("expected a partial crawl, got %v", first.URLs())
+	}
+	state.Close()
+
+	// a restart reopens the file
+	state, err = NewSQLiteState(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+	f := newCountingFetcher(0)
+	c = Crawler{Fetcher: f, State: state}
+	res, err := c.Crawl(context.Background(), "http://golang.org/", 4)
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+
+	if len(res.Pages) != 5 {
+		t.Errorf("expected the whole site, got %v", res.URLs())
+	}
+	for u := range first.Pages {
+		if f.fetches[u] != 0 {
+			t.Errorf("%s fetched again after resume", u)
+		}
+	}
+	if p := res.Pages["http://golang.org/cmd/"]; p.Err == nil || p.Err.Error() != "not found: http://golang.org/cmd/" {
+		t.Errorf("expected the saved error, got %v", p.Err)
+	}
+	if p := res.Pages["http://golang.org/pkg/"]; p.Body != "body of http://golang.org/pkg/" || len(p.Links) != 4 || p.Depth != 1 {
+		t.Errorf("unexpected page %+v", p)
+	}
+}
+
+func TestCrawlResumeDeeper(t *testing.T) {
+	state := NewMemoryState()
+	if _, err := (&Crawler{Fetcher: newCountingFetcher(0), State: state}).Crawl(context.Background(), "http://golang.org/", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newCountingFetcher(0)
+	res, err := (&Crawler{Fetcher: f, State: state}).Crawl(context.Background(), "http://golang.org/", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pages) != 5 {
+		t.Errorf("expected the whole site, got %v", res.URLs())
+	}
+	if len(f.fetches) != 2 || f.fetches["http://golang.org/pkg/fmt/"] != 1 || f.fetches["http://golang.org/pkg/os/"] != 1 {
+		t.Errorf("expected only the pages below the old depth fetched, got %v", f.fetches)
+	}
+}
+
+func TestCrawlTTL(t *testing.T) {
+	for name, newState := range map[string]func(t *testing.T) State{
+		"memory": func(t *testing.T) State { return NewMemoryState() },
+		"sqlite": func(t *testing.T) State {
+			s, err := NewSQLiteState(":memory:")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			state := newState(t)
+			old := time.Now().Add(-time.Hour)
+			for _, p := range []*Page{
+				{URL: "http://golang.org/", Body: "old", Links: []string{"http://golang.org/pkg/"}, Fetched: old},
+				{URL: "http://golang.org/pkg/", Depth: 1, Body: "fresh", Fetched: time.Now()},
+			} {
+				if err := state.Save(p, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f := newCountingFetcher(0)
+			res, err := (&Crawler{Fetcher: f, State: state, TTL: time.Minute}).Crawl(context.Background(), "http://golang.org/", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(f.fetches) != 2 || f.fetches["http://golang.org/"] != 1 || f.fetches["http://golang.org/cmd/"] != 1 {
+				t.Errorf("expected the stale root and its new link fetched, got %v", f.fetches)
+			}
+			if res.Pages["http://golang.org/"].Body != "body of http://golang.org/" || res.Pages["http://golang.org/pkg/"].Body != "fresh" {
+				t.Errorf("unexpected bodies %q %q", res.Pages["http://golang.org/"].Body, res.Pages["http://golang.org/pkg/"].Body)
+			}
+
+			f = newCountingFetcher(0)
+			if _, err := (&Crawler{Fetcher: f, State: state}).Crawl(context.Background(), "http://golang.org/", 2); err != nil {
+				t.Fatal(err)
+			}
+			if len(f.fetches) != 0 {
+				t.Errorf("expected nothing fetched without TTL, got %v", f.fetches)
+			}
+		})
+	}
+}
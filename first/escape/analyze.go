@@ -0,0 +1,322 @@
+package escape
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Config selects the code to analyze.
+type Config struct {
+	// Dir is the package directory, or the directory of Files.
+	Dir string
+	// Files are the files to analyze instead of the whole package, files
+	// excluded by build constraints like //go:build ignore samples
+	// are analyzed as well.
+	Files []string
+	// Measure selects by name the functions to run with testing.AllocsPerRun,
+	// none if nil. Only functions without receivers and type parameters can
+	// be measured, they are called with zero arguments. A function panicking
+	// on them isn't measured, one blocking on them blocks until ctx is done.
+	Measure *regexp.Regexp
+	// Runs is the AllocsPerRun runs, 100 if zero.
+	Runs int
+}
+
+// Analyze builds the code with -gcflags=-m=2 and measures the selected
+// functions. The code is copied to a temporary directory next to it, so it
+// builds within the same module.
+func Analyze(ctx context.Context, cfg Config) (*Report, error) {
+	files := cfg.Files
+	if len(files) == 0 {
+		pkg, err := build.ImportDir(cfg.Dir, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = pkg.GoFiles
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", cfg.Dir)
+	}
+
+	tmp, err := ioutil.TempDir(cfg.Dir, "_escape")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range files {
+		src, err := ioutil.ReadFile(filepath.Join(cfg.Dir, name))
+		if err != nil {
+			return nil, err
+		}
+		src = dropBuildConstraints(src)
+		// parse the copy, so the lines match the compiler output
+		f, err := parser.ParseFile(fset, filepath.Base(name), src, 0)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, f)
+		if err := ioutil.WriteFile(filepath.Join(tmp, filepath.Base(name)), src, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "build", "-gcflags=-m=2", "-o", os.DevNull, ".")
+	cmd.Dir = tmp
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go build: %v\n%s", err, out.Bytes())
+	}
+	decisions, inline, err := Parse(&out)
+	if err != nil {
+		return nil, err
+	}
+	report := newReport(fset, parsed, decisions, inline)
+
+	if cfg.Measure == nil {
+		return report, nil
+	}
+	names, err := packageNames(ctx, tmp, parsed)
+	if err != nil {
+		return nil, err
+	}
+	targets := measurable(fset, parsed, cfg.Measure, names)
+	if len(targets) == 0 {
+		return report, nil
+	}
+	allocs, err := measure(ctx, tmp, parsed[0].Name.Name, targets, cfg.Runs)
+	if err != nil {
+		return nil, err
+	}
+	for name, n := range allocs {
+		if fn := report.Func(name); fn != nil {
+			fn.Allocs, fn.Measured = n, true
+		}
+	}
+	return report, nil
+}
+
+var constraintRe = regexp.MustCompile(`(?m)^(//go:build .*|// \+build .*)\n`)
+
+// dropBuildConstraints removes the build constraints, keeping the lines
+// so the positions still match the original file.
+func dropBuildConstraints(src []byte) []byte {
+	return constraintRe.ReplaceAll(src, []byte("\n"))
+}
+
+type target struct {
+	Name string
+	Call string
+	// Imports are the packages of the argument types by their names in Call.
+	Imports map[string]string
+}
+
+// packageNames returns the package names of the files' imports by path.
+func packageNames(ctx context.Context, dir string, files []*ast.File) (map[string]string, error) {
+	args := []string{"list", "-f", "{{.ImportPath}} {{.Name}}"}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil || seen[path] || path == "C" {
+				continue
+			}
+			seen[path] = true
+			args = append(args, path)
+		}
+	}
+	names := make(map[string]string)
+	if len(seen) == 0 {
+		return names, nil
+	}
+
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list: %v\n%s", err, stderr.Bytes())
+	}
+	s := bufio.NewScanner(&out)
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) == 2 {
+			names[fields[0]] = fields[1]
+		}
+	}
+	return names, s.Err()
+}
+
+// measurable returns the calls of the selected functions with zero arguments,
+// names are the package names of the imports by path.
+func measurable(fset *token.FileSet, files []*ast.File, sel *regexp.Regexp, names map[string]string) []target {
+	var targets []target
+	for _, f := range files {
+		// the imports of the file by their names in it
+		imports := make(map[string]string)
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			name := names[path]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			imports[name] = path
+		}
+
+	decls:
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil || fd.Name.Name == "main" || fd.Name.Name == "init" ||
+				fd.Name.Name == "_" || !sel.MatchString(fd.Name.Name) || hasTypeParams(fd) {
+				continue
+			}
+
+			var args []string
+			used := make(map[string]string)
+			for _, field := range fd.Type.Params.List {
+				if _, ok := field.Type.(*ast.Ellipsis); ok {
+					continue
+				}
+				// the qualified identifiers need the imports in the harness,
+				// the types of dot imports can't be told from the local ones
+				if _, ok := imports["."]; ok {
+					continue decls
+				}
+				qualified := true
+				ast.Inspect(field.Type, func(n ast.Node) bool {
+					if s, ok := n.(*ast.SelectorExpr); ok {
+						if x, ok := s.X.(*ast.Ident); ok {
+							path, ok := imports[x.Name]
+							qualified = qualified && ok
+							used[x.Name] = path
+						}
+						return false
+					}
+					return true
+				})
+				if !qualified {
+					continue decls
+				}
+
+				var typ bytes.Buffer
+				printer.Fprint(&typ, fset, field.Type)
+				n := len(field.Names)
+				if n == 0 {
+					n = 1
+				}
+				for i := 0; i < n; i++ {
+					args = append(args, "*new("+typ.String()+")")
+				}
+			}
+			targets = append(targets, target{
+				Name:    fd.Name.Name,
+				Call:    fd.Name.Name + "(" + strings.Join(args, ", ") + ")",
+				Imports: used,
+			})
+		}
+	}
+	return targets
+}
+
+const allocsMarker = "escape-allocs"
+
+var harness = template.Must(template.New("harness").Parse(`package {{.Package}}
+
+import (
+{{- range $name, $path := .Imports}}
+	{{$name}} {{printf "%q" $path}}
+{{- end}}
+)
+
+func TestEscapeAllocs(t *testing.T) {
+{{- range .Targets}}
+	func() {
+		// a function panicking on its zero arguments isn't measured
+		defer func() { recover() }()
+		fmt.Printf("{{$.Marker}} %s %v\n", {{printf "%q" .Name}}, testing.AllocsPerRun({{$.Runs}}, func() { {{.Call}} }))
+	}()
+{{- end}}
+}
+`))
+
+// measure runs the targets in a generated test and returns their allocs per run.
+func measure(ctx context.Context, dir, pkg string, targets []target, runs int) (map[string]float64, error) {
+	if runs <= 0 {
+		runs = 100
+	}
+	// the harness imports fmt and testing and the packages of the arguments,
+	// a target needing another package by either name is left out
+	imports := map[string]string{"fmt": "fmt", "testing": "testing"}
+	var selected []target
+targets:
+	for _, t := range targets {
+		for name, path := range t.Imports {
+			if p, ok := imports[name]; ok && p != path {
+				continue targets
+			}
+		}
+		for name, path := range t.Imports {
+			imports[name] = path
+		}
+		selected = append(selected, t)
+	}
+
+	var src bytes.Buffer
+	err := harness.Execute(&src, map[string]interface{}{
+		"Package": pkg,
+		"Imports": imports,
+		"Targets": selected,
+		"Marker":  allocsMarker,
+		"Runs":    runs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "escape_allocs_test.go"), src.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "test", "-count=1", "-v", "-run", "^TestEscapeAllocs$", ".")
+	cmd.Dir = dir
+	// the measured functions may print, only the markers on stdout count
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go test: %v\n%s%s", err, out.Bytes(), stderr.Bytes())
+	}
+
+	allocs := make(map[string]float64)
+	s := bufio.NewScanner(&out)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 3 || fields[0] != allocsMarker {
+			continue
+		}
+		n, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse allocs of %s: %w", fields[1], err)
+		}
+		allocs[fields[1]] = n
+	}
+	return allocs, s.Err()
+}
@@ -0,0 +1,262 @@
+// Package escape reports the compiler's escape analysis decisions per
+// function and cross-checks them with the allocations measured by
+// testing.AllocsPerRun.
+package escape
+
+import (
+	"bufio"
+	"go/ast"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Kind string
+
+const (
+	MovedToHeap   Kind = "moved to heap"
+	EscapesToHeap Kind = "escapes to heap"
+	DoesNotEscape Kind = "does not escape"
+	LeakingParam  Kind = "leaking param"
+)
+
+// Heap reports whether the decision allocates on the heap.
+func (k Kind) Heap() bool {
+	return k == MovedToHeap || k == EscapesToHeap
+}
+
+// Decision is an escape analysis decision about a variable or an expression.
+type Decision struct {
+	File string
+	Line int
+	Col  int
+	// Subject is the variable, parameter or expression the decision is about.
+	Subject string
+	Kind    Kind
+	// Detail is the rest of a leaking param decision, like "to result ~r0 level=0".
+	Detail string
+	// Reason is the data flow that made the value escape, from the variable
+	// to where it's stored, as reported by -gcflags=-m=2.
+	Reason []string
+}
+
+// Why returns the last step of Reason: the place which makes the value escape.
+func (d Decision) Why() string {
+	if len(d.Reason) == 0 {
+		return ""
+	}
+	return d.Reason[len(d.Reason)-1]
+}
+
+// Func is the report of a function.
+type Func struct {
+	Name string
+	File string
+	Line int
+	// Inline is the inlining decision, like "can inline" or
+	// "cannot inline: marked go:noinline".
+	Inline    string
+	Decisions []Decision
+
+	// Allocs is the result of testing.AllocsPerRun if Measured.
+	Allocs   float64
+	Measured bool
+}
+
+// HeapDecisions counts the decisions which allocate on the heap.
+func (f *Func) HeapDecisions() int {
+	n := 0
+	for _, d := range f.Decisions {
+		if d.Kind.Heap() {
+			n++
+		}
+	}
+	return n
+}
+
+// Check compares the escape decisions with the measured allocations. Allocs
+// of callees are measured too, so they are expected to be at least the
+// heap decisions, and a function without heap decisions may still allocate.
+func (f *Func) Check() string {
+	if !f.Measured {
+		return "not measured"
+	}
+	heap := f.HeapDecisions()
+	switch {
+	case heap > 0 && f.Allocs == 0:
+		return "mismatch: heap decisions but no allocs"
+	case heap == 0 && f.Allocs > 0:
+		return "callees allocate"
+	default:
+		return "ok"
+	}
+}
+
+type Report struct {
+	Funcs []*Func
+}
+
+// Func returns the function named like the compiler does, e.g. "(*T).M".
+func (r *Report) Func(name string) *Func {
+	for _, f := range r.Funcs {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+var (
+	// ./user.go:29:2: moved to heap: u
+	lineRe = regexp.MustCompile(`^(.+?\.go):(\d+):(\d+): (.*)$`)
+	// explanations are indented under their header at the same position
+	flowRe      = regexp.MustCompile(`^\s+from (.*?)(?: at \S+)?$`)
+	leakRe      = regexp.MustCompile(`^leaking param(?: content)?: (\S+)\s*(.*)$`)
+	canInlineRe = regexp.MustCompile(`^can inline (\S+?)(?: with cost (\d+))?(?: as: .*)?$`)
+	noInlineRe  = regexp.MustCompile(`^cannot inline (\S+?): (.*)$`)
+)
+
+// Parse reads the output of go build -gcflags=-m=2 into decisions and
+// inlining results, keyed by base file name and function name.
+func Parse(r io.Reader) ([]Decision, map[string]string, error) {
+	var decisions []Decision
+	inline := make(map[string]string)
+	// flows collects the explanations per position until the decision comes
+	flows := make(map[string][]string)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		m := lineRe.FindStringSubmatch(s.Text())
+		if m == nil {
+			continue
+		}
+		pos := m[1] + ":" + m[2] + ":" + m[3]
+		msg := m[4]
+
+		if fm := flowRe.FindStringSubmatch(msg); fm != nil {
+			flows[pos] = append(flows[pos], fm[1])
+			continue
+		}
+		if strings.HasPrefix(msg, " ") || strings.HasSuffix(msg, ":") {
+			// flow lines and explanation headers
+			continue
+		}
+		if im := canInlineRe.FindStringSubmatch(msg); im != nil {
+			inline[im[1]] = "can inline"
+			if im[2] != "" {
+				inline[im[1]] += " (cost " + im[2] + ")"
+			}
+			continue
+		}
+		if im := noInlineRe.FindStringSubmatch(msg); im != nil {
+			inline[im[1]] = "cannot inline: " + im[2]
+			continue
+		}
+
+		d := Decision{File: baseName(m[1])}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Col, _ = strconv.Atoi(m[3])
+		switch {
+		case strings.HasPrefix(msg, "moved to heap: "):
+			d.Kind, d.Subject = MovedToHeap, strings.TrimPrefix(msg, "moved to heap: ")
+		case strings.HasSuffix(msg, " escapes to heap"):
+			d.Kind, d.Subject = EscapesToHeap, strings.TrimSuffix(msg, " escapes to heap")
+		case strings.HasSuffix(msg, " does not escape"):
+			d.Kind, d.Subject = DoesNotEscape, strings.TrimSuffix(msg, " does not escape")
+		default:
+			lm := leakRe.FindStringSubmatch(msg)
+			if lm == nil {
+				continue
+			}
+			d.Kind, d.Subject, d.Detail = LeakingParam, lm[1], lm[2]
+			if strings.HasPrefix(msg, "leaking param content") {
+				d.Detail = strings.TrimSpace("content " + d.Detail)
+			}
+		}
+		d.Reason = flows[pos]
+		delete(flows, pos)
+		decisions = append(decisions, d)
+	}
+	return decisions, inline, s.Err()
+}
+
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// newReport attributes the decisions to the function declarations of files.
+func newReport(fset *token.FileSet, files []*ast.File, decisions []Decision, inline map[string]string) *Report {
+	r := &Report{}
+	type span struct {
+		fn         *Func
+		file       string
+		start, end int
+	}
+	var spans []span
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			start, end := fset.Position(fd.Pos()), fset.Position(fd.End())
+			fn := &Func{Name: funcName(fd), File: baseName(start.Filename), Line: start.Line}
+			fn.Inline = inline[fn.Name]
+			r.Funcs = append(r.Funcs, fn)
+			spans = append(spans, span{fn: fn, file: fn.File, start: start.Line, end: end.Line})
+		}
+	}
+
+	for _, d := range decisions {
+		for _, s := range spans {
+			if s.file == d.File && s.start <= d.Line && d.Line <= s.end {
+				s.fn.Decisions = append(s.fn.Decisions, d)
+				break
+			}
+		}
+	}
+	for _, fn := range r.Funcs {
+		sort.SliceStable(fn.Decisions, func(i, j int) bool {
+			a, b := fn.Decisions[i], fn.Decisions[j]
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Col < b.Col
+		})
+	}
+	sort.SliceStable(r.Funcs, func(i, j int) bool {
+		if r.Funcs[i].File != r.Funcs[j].File {
+			return r.Funcs[i].File < r.Funcs[j].File
+		}
+		return r.Funcs[i].Line < r.Funcs[j].Line
+	})
+	return r
+}
+
+// funcName names fd the way the compiler does: f, T.M or (*T).M.
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	typ := fd.Recv.List[0].Type
+	star := false
+	if se, ok := typ.(*ast.StarExpr); ok {
+		star, typ = true, se.X
+	}
+	// generic receivers aren't identifiers, the compiler adds their type parameters
+	name := "?"
+	if id, ok := typ.(*ast.Ident); ok {
+		name = id.Name
+	}
+	if star {
+		return "(*" + name + ")." + fd.Name.Name
+	}
+	return name + "." + fd.Name.Name
+}
@@ -0,0 +1,132 @@
+package escape
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	f, err := os.Open("testdata/m2.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	decisions, inline, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Decision{
+		{File: "x.go", Line: 7, Col: 7, Subject: "t", Kind: LeakingParam, Detail: "content", Reason: []string{"t.s (dot of pointer)"}},
+		{File: "x.go", Line: 7, Col: 38, Subject: "append", Kind: EscapesToHeap, Reason: []string{"append(t.s, x) (spill)", "t.s = append(t.s, x) (assign)"}},
+		{File: "x.go", Line: 9, Col: 11, Subject: "p", Kind: LeakingParam, Detail: "to result ~r0 level=0", Reason: []string{"return p (return)"}},
+		{File: "x.go", Line: 11, Col: 29, Subject: "... argument", Kind: DoesNotEscape},
+		{File: "x.go", Line: 13, Col: 2, Subject: "u", Kind: MovedToHeap, Reason: []string{"&u (address-of)", "return &u (return)"}},
+	}
+	if len(decisions) != len(want) {
+		t.Fatalf("expected %d decisions, got %+v", len(want), decisions)
+	}
+	for i := range want {
+		if got := decisions[i]; got.File != want[i].File || got.Line != want[i].Line || got.Col != want[i].Col ||
+			got.Subject != want[i].Subject || got.Kind != want[i].Kind || got.Detail != want[i].Detail ||
+			strings.Join(got.Reason, "|") != strings.Join(want[i].Reason, "|") {
+			t.Errorf("decision %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+	if decisions[4].Why() != "return &u (return)" {
+		t.Errorf("unexpected why %q", decisions[4].Why())
+	}
+
+	if inline["(*T).Add"] != "can inline (cost 14)" || inline["mk"] != "cannot inline: marked go:noinline" {
+		t.Errorf("unexpected inlining %v", inline)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the samples")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not found")
+	}
+
+	report, err := Analyze(context.Background(), Config{
+		Dir:     "../cmd/user",
+		Files:   []string{"main.go"},
+		Measure: regexp.MustCompile("^createUser"),
+		Runs:    10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1, v2 := report.Func("createUserV1"), report.Func("createUserV2")
+	if v1 == nil || v2 == nil {
+		t.Fatalf("expected both createUser functions, got %+v", report.Funcs)
+	}
+	if v1.HeapDecisions() != 0 || !v1.Measured || v1.Allocs != 0 || v1.Check() != "ok" {
+		t.Errorf("expected createUserV1 on the stack, got %+v", v1)
+	}
+	if v2.HeapDecisions() != 1 || v2.Decisions[0].Subject != "u" || v2.Decisions[0].Kind != MovedToHeap ||
+		!v2.Measured || v2.Allocs != 1 || v2.Check() != "ok" {
+		t.Errorf("expected u of createUserV2 moved to heap, got %+v", v2)
+	}
+	if !strings.Contains(v1.Inline, "go:noinline") {
+		t.Errorf("expected the noinline directive, got %q", v1.Inline)
+	}
+	if main := report.Func("main"); main == nil || main.Measured {
+		t.Errorf("expected main not measured, got %+v", main)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteTable(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`createUserV2\s+main.go:28\s+u\s+moved to heap\s+return &u \(return\)\s+1\s+ok`).Match(buf.Bytes()) {
+		t.Errorf("unexpected table\n%s", buf.String())
+	}
+
+	entries, _ := os.ReadDir("../cmd/user")
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "_escape") {
+			t.Errorf("temporary directory %s left behind", e.Name())
+		}
+	}
+}
+
+func TestAnalyzeParams(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the samples")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not found")
+	}
+
+	report, err := Analyze(context.Background(), Config{
+		Dir:     "testdata/params",
+		Measure: regexp.MustCompile("."),
+		Runs:    10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Host panics on the nil URL
+	for name, allocs := range map[string]float64{"Host": -1, "Read": 0, "Format": 0, "Upper": 1, "Join": 0} {
+		fn := report.Func(name)
+		switch {
+		case fn == nil:
+			t.Errorf("expected %s in the report", name)
+		case allocs < 0 && fn.Measured:
+			t.Errorf("expected %s not measured, got %+v", name, fn)
+		case allocs >= 0 && (!fn.Measured || fn.Allocs != allocs):
+			t.Errorf("expected %s measured with %v allocs, got %+v", name, allocs, fn)
+		}
+	}
+}
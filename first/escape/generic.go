@@ -0,0 +1,10 @@
+//go:build go1.18
+// +build go1.18
+
+package escape
+
+import "go/ast"
+
+func hasTypeParams(fd *ast.FuncDecl) bool {
+	return fd.Type.TypeParams != nil && len(fd.Type.TypeParams.List) > 0
+}
@@ -0,0 +1,47 @@
+package escape
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// WriteTable writes a row per decision, and a row for each function without
+// decisions, with the measured allocations of the function.
+func (r *Report) WriteTable(w io.Writer, all bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "FUNCTION\tLINE\tVARIABLE\tDECISION\tWHY\tALLOCS/OP\tCHECK")
+	for _, fn := range r.Funcs {
+		allocs, check := "-", fn.Check()
+		if fn.Measured {
+			allocs = strconv.FormatFloat(fn.Allocs, 'f', -1, 64)
+		}
+
+		rows := 0
+		for _, d := range fn.Decisions {
+			if !all && !d.Kind.Heap() {
+				continue
+			}
+			decision := string(d.Kind)
+			if d.Detail != "" {
+				decision += " " + d.Detail
+			}
+			fmt.Fprintf(tw, "%s\t%s:%d\t%s\t%s\t%s\t%s\t%s\n",
+				fn.Name, d.File, d.Line, d.Subject, decision, orDash(d.Why()), allocs, check)
+			rows++
+		}
+		if rows == 0 {
+			fmt.Fprintf(tw, "%s\t%s:%d\t-\t%s\t-\t%s\t%s\n",
+				fn.Name, fn.File, fn.Line, orDash(fn.Inline), allocs, check)
+		}
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+// Package params has functions taking arguments from other packages, for
+// the measurement harness.
+package params
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	str "strconv"
+	"time"
+)
+
+func Host(u *url.URL) string {
+	return u.Host
+}
+
+func Read(r io.Reader, buf []byte) int {
+	if r == nil {
+		return 0
+	}
+	n, _ := r.Read(buf)
+	return n
+}
+
+func Format(d time.Duration, base int) string {
+	return str.FormatInt(int64(d), base+10)
+}
+
+func Upper(s string, b *strings.Builder) *strings.Builder {
+	if b == nil {
+		b = new(strings.Builder)
+	}
+	b.WriteString(strings.ToUpper(s))
+	return b
+}
+
+func Join(parts ...string) string {
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,8 @@
+module github.com/awnzl/workshops/first
+
+go 1.16
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.8
+	golang.org/x/net v0.0.0-20200822124328-c89045814202
+)
@@ -0,0 +1,322 @@
+// Package validate checks structs against the rules in their validate tags:
+//
+//	type Task struct {
+//		Alias    string   `json:"alias" validate:"required,max=64"`
+//		Category []string `json:"cat" validate:"max=3,dive,oneof=urgent important general"`
+//	}
+//
+// The rules are separated by commas and checked in order:
+//
+//	required   the value isn't the zero value
+//	omitempty  the other rules are skipped for the zero value
+//	min=N      strings have at least N characters, slices and maps N
+//	           elements, numbers are at least N
+//	max=N      the same with at most N
+//	len=N      strings have exactly N characters, slices and maps N elements
+//	oneof=a b  the string or number is one of the space separated values
+//	dive       the rules after it apply to the elements of a slice, an
+//	           array or a map instead
+//
+// Nested structs, pointers to structs and the structs in slices and maps
+// are checked too. Errors name the fields by their json tag, the names the
+// API clients know, with a path like "reminders[2].at".
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is a value breaking a rule.
+type FieldError struct {
+	// Path is the value in the struct, like "tags[1]".
+	Path string
+	// Rule is the rule's name, Param its parameter if any.
+	Rule  string
+	Param string
+	Msg   string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + " " + e.Msg
+}
+
+// Errors are all the rules a struct breaks.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Struct checks v, a struct or a pointer to one, against its validate tags.
+// The error is Errors when v breaks rules, it's another error for the tags
+// which can't be parsed.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: Struct of %T, not a struct", v)
+	}
+
+	var errs Errors
+	if err := checkStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type rule struct {
+	name  string
+	param string
+	// n is the parameter of min, max and len
+	n float64
+	// values are the parameters of oneof
+	values []string
+}
+
+type structField struct {
+	index int
+	name  string
+	rules []rule
+	err   error
+}
+
+// fieldCache is a map[reflect.Type][]structField.
+var fieldCache sync.Map
+
+func cachedFields(t reflect.Type) []structField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]structField)
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := sf.Name
+		if tag := strings.Split(sf.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			name = tag
+		}
+		rules, err := parseRules(sf.Tag.Get("validate"))
+		if err != nil {
+			err = fmt.Errorf("validate: %s.%s: %v", t, sf.Name, err)
+		}
+		fields = append(fields, structField{index: i, name: name, rules: rules, err: err})
+	}
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.([]structField)
+}
+
+func parseRules(tag string) ([]rule, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	var rules []rule
+	for _, s := range strings.Split(tag, ",") {
+		r := rule{name: s}
+		if i := strings.IndexByte(s, '='); i >= 0 {
+			r.name, r.param = s[:i], s[i+1:]
+		}
+		switch r.name {
+		case "required", "omitempty", "dive":
+			if r.param != "" {
+				return nil, fmt.Errorf("rule %s takes no parameter", r.name)
+			}
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(r.param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: bad parameter %q", r.name, r.param)
+			}
+			r.n = n
+		case "oneof":
+			r.values = strings.Fields(r.param)
+			if len(r.values) == 0 {
+				return nil, fmt.Errorf("rule oneof without values")
+			}
+		default:
+			return nil, fmt.Errorf("unknown rule %q", r.name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func checkStruct(v reflect.Value, path string, errs *Errors) error {
+	for _, f := range cachedFields(v.Type()) {
+		if f.err != nil {
+			return f.err
+		}
+		p := f.name
+		if path != "" {
+			p = path + "." + f.name
+		}
+		if err := check(v.Field(f.index), p, f.rules, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check applies the rules to v, then checks the structs in it.
+func check(v reflect.Value, path string, rules []rule, errs *Errors) error {
+	for i, r := range rules {
+		switch r.name {
+		case "omitempty":
+			if v.IsZero() {
+				return nil
+			}
+		case "dive":
+			return checkElems(v, path, rules[i+1:], errs)
+		default:
+			msg, err := apply(v, r)
+			if err != nil {
+				return fmt.Errorf("validate: %s: %v", path, err)
+			}
+			if msg != "" {
+				*errs = append(*errs, &FieldError{Path: path, Rule: r.name, Param: r.param, Msg: msg})
+				// the other rules would repeat the same problem
+				return nil
+			}
+		}
+	}
+	return checkElems(v, path, nil, errs)
+}
+
+// checkElems checks the elements of v with the rules, and the structs in v.
+func checkElems(v reflect.Value, path string, rules []rule, errs *Errors) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return checkElems(v.Elem(), path, rules, errs)
+	case reflect.Struct:
+		return checkStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		if rules == nil && !hasStructs(v.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := check(v.Index(i), fmt.Sprintf("%s[%d]", path, i), rules, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if rules == nil && !hasStructs(v.Type().Elem()) {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := check(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), rules, errs); err != nil {
+				return err
+			}
+		}
+	default:
+		if rules != nil {
+			return fmt.Errorf("validate: %s: dive into %s", path, v.Type())
+		}
+	}
+	return nil
+}
+
+// hasStructs tells whether the values of t may contain structs to check.
+func hasStructs(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasStructs(t.Elem())
+	case reflect.Struct, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// apply returns the message of the broken rule r, the error is for rules
+// which don't apply to the kind of v.
+func apply(v reflect.Value, r rule) (string, error) {
+	if r.name == "required" {
+		if v.IsZero() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+			return "is required", nil
+		}
+		return "", nil
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			// required is the rule for nil
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if r.name == "oneof" {
+		var s string
+		switch v.Kind() {
+		case reflect.String:
+			s = v.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = fmt.Sprint(v.Interface())
+		default:
+			return "", fmt.Errorf("oneof on %s", v.Type())
+		}
+		for _, value := range r.values {
+			if s == value {
+				return "", nil
+			}
+		}
+		return "must be one of " + strings.Join(r.values, ", "), nil
+	}
+
+	// min, max and len compare a size, or a number
+	var size float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, unit = float64(v.Len()), "elements"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	default:
+		return "", fmt.Errorf("%s on %s", r.name, v.Type())
+	}
+	if unit == "" && r.name == "len" {
+		return "", fmt.Errorf("len on %s", v.Type())
+	}
+
+	var broken bool
+	var what string
+	switch r.name {
+	case "min":
+		broken, what = size < r.n, "at least"
+	case "max":
+		broken, what = size > r.n, "at most"
+	case "len":
+		broken, what = size != r.n, "exactly"
+	}
+	if !broken {
+		return "", nil
+	}
+	if unit == "" {
+		return "must be " + what + " " + r.param, nil
+	}
+	return "must have " + what + " " + r.param + " " + unit, nil
+}
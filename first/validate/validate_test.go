@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type Reminder struct {
+	At   int64  `json:"at" validate:"min=1"`
+	Note string `json:"note,omitempty" validate:"omitempty,min=3"`
+}
+
+type Task struct {
+	Alias     string            `json:"alias" validate:"required,max=8"`
+	Priority  string            `json:"priority" validate:"oneof=urgent important general"`
+	Tags      []string          `json:"tags" validate:"max=2,dive,required,len=3"`
+	Estimate  *int              `validate:"min=1,max=10"`
+	Owner     *Reminder         `json:"owner" validate:"required"`
+	Reminders []Reminder        `json:"reminders"`
+	Labels    map[string]string `json:"labels" validate:"dive,oneof=a b"`
+	Level     uint8             `validate:"oneof=1 2 3"`
+	private   int
+}
+
+func valid() Task {
+	est := 5
+	return Task{
+		Alias:     "deploy",
+		Priority:  "urgent",
+		Tags:      []string{"ops"},
+		Estimate:  &est,
+		Owner:     &Reminder{At: 1},
+		Reminders: []Reminder{{At: 2, Note: "call"}},
+		Labels:    map[string]string{"x": "a"},
+		Level:     2,
+	}
+}
+
+func TestStruct(t *testing.T) {
+	if err := Struct(valid()); err != nil {
+		t.Fatalf("expected a valid task, got %v", err)
+	}
+
+	est := 11
+	tests := []struct {
+		change func(*Task)
+		want   string
+	}{
+		{func(t *Task) { t.Alias = "" }, "alias is required"},
+		{func(t *Task) { t.Alias = "éééééééé" }, ""},
+		{func(t *Task) { t.Alias = "123456789" }, "alias must have at most 8 characters"},
+		{func(t *Task) { t.Priority = "later" }, "priority must be one of urgent, important, general"},
+		{func(t *Task) { t.Tags = []string{"a", "b", "c"} }, "tags must have at most 2 elements"},
+		{func(t *Task) { t.Tags = []string{"ops", ""} }, "tags[1] is required"},
+		{func(t *Task) { t.Tags = []string{"dev", "test"} }, "tags[1] must have exactly 3 characters"},
+		{func(t *Task) { t.Estimate = nil }, ""},
+		{func(t *Task) { t.Estimate = &est }, "Estimate must be at most 10"},
+		{func(t *Task) { t.Owner = nil }, "owner is required"},
+		{func(t *Task) { t.Owner.At = 0 }, "owner.at must be at least 1"},
+		{func(t *Task) { t.Reminders[0].Note = "" }, ""},
+		{func(t *Task) { t.Reminders = append(t.Reminders, Reminder{At: 3, Note: "no"}) }, "reminders[1].note must have at least 3 characters"},
+		{func(t *Task) { t.Labels["y"] = "c" }, "labels[y] must be one of a, b"},
+		{func(t *Task) { t.Level = 4 }, "Level must be one of 1, 2, 3"},
+		{func(t *Task) { t.Alias, t.Level = "", 0 }, "alias is required; Level must be one of 1, 2, 3"},
+	}
+	for _, tt := range tests {
+		task := valid()
+		tt.change(&task)
+		err := Struct(&task)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			continue
+		}
+		var errs Errors
+		if !errors.As(err, &errs) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, err)
+		}
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	task := valid()
+	task.Tags = []string{"ops", "x"}
+	errs := Struct(task).(Errors)
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+	want := FieldError{Path: "tags[1]", Rule: "len", Param: "3", Msg: "must have exactly 3 characters"}
+	if *errs[0] != want {
+		t.Errorf("expected %+v, got %+v", want, *errs[0])
+	}
+}
+
+func TestBadRules(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{42, "validate: Struct of int, not a struct"},
+		{struct {
+			A string `validate:"required=yes"`
+		}{}, "rule required takes no parameter"},
+		{struct {
+			A string `validate:"max=ten"`
+		}{}, `rule max: bad parameter "ten"`},
+		{struct {
+			A string `validate:"email"`
+		}{}, `unknown rule "email"`},
+		{struct {
+			A bool `validate:"min=1"`
+		}{}, "validate: A: min on bool"},
+		{struct {
+			A int `validate:"len=1"`
+		}{}, "validate: A: len on int"},
+		{struct {
+			A string `validate:"dive,min=1"`
+		}{"x"}, "validate: A: dive into string"},
+	} {
+		err := Struct(tt.v)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%#v: expected %q, got %v", tt.v, tt.want, err)
+		}
+		var errs Errors
+		if errors.As(err, &errs) {
+			t.Errorf("%#v: expected a rule error, got %v", tt.v, err)
+		}
+	}
+}
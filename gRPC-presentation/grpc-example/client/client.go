@@ -0,0 +1,135 @@
+// Package client is a ChatService client with credentials, default deadlines,
+// retries and keepalive configured.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/grpc-example/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	DefaultTimeout = 5 * time.Second
+
+	// retryServiceConfig retries calls failed with Unavailable with exponential backoff.
+	retryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "chat.ChatService"}],
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+)
+
+var defaultKeepalive = keepalive.ClientParameters{
+	Time:                30 * time.Second,
+	Timeout:             10 * time.Second,
+	PermitWithoutStream: false,
+}
+
+type Config struct {
+	Address string
+	// Token is sent as "Authorization: Bearer <token>" with every call.
+	Token string
+	// Insecure disables TLS, otherwise system roots are used.
+	Insecure bool
+	// Timeout is the deadline of unary calls made without one, DefaultTimeout if zero.
+	Timeout   time.Duration
+	Keepalive *keepalive.ClientParameters
+	// DialOptions are appended to the client defaults.
+	DialOptions []grpc.DialOption
+}
+
+// Client embeds the generated client, so every ChatService RPC is available on it.
+type Client struct {
+	pb.ChatServiceClient
+
+	conn *grpc.ClientConn
+}
+
+// BasicToken builds the token the server expects from user and password.
+func BasicToken(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
+
+func New(cfg Config) (*Client, error) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	kp := defaultKeepalive
+	if cfg.Keepalive != nil {
+		kp = *cfg.Keepalive
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(retryServiceConfig),
+		grpc.WithKeepaliveParams(kp),
+		grpc.WithChainUnaryInterceptor(timeoutInterceptor(timeout)),
+	}
+	if cfg.Insecure {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	}
+	if cfg.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerToken{token: cfg.Token, secure: !cfg.Insecure}))
+	}
+	opts = append(opts, cfg.DialOptions...)
+
+	conn, err := grpc.Dial(cfg.Address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", cfg.Address, err)
+	}
+	return &Client{
+		ChatServiceClient: pb.NewChatServiceClient(conn),
+		conn:              conn,
+	}, nil
+}
+
+// Conn returns the underlying connection, e.g. to call methods by name.
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// timeoutInterceptor sets a deadline on unary calls that don't have one.
+// Streams are left alone, they are expected to live longer.
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t bearerToken) RequireTransportSecurity() bool {
+	return t.secure
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grpc-example/interceptors"
+	"github.com/grpc-example/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// flakyChat fails the first failures calls with Unavailable.
+type flakyChat struct {
+	pb.UnimplementedChatServiceServer
+
+	failures int32
+	calls    int32
+	delay    time.Duration
+}
+
+func (s *flakyChat) SayHello(ctx context.Context, in *pb.Message) (*pb.Message, error) {
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	user, _ := interceptors.UserFromContext(ctx)
+	return &pb.Message{Body: user}, nil
+}
+
+func newTestClient(t *testing.T, chat *flakyChat, cfg Config) *Client {
+	lis := bufconn.Listen(1 << 20)
+	authMD := interceptors.AuthMD{}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(authMD.UnaryInterceptor()))
+	pb.RegisterChatServiceServer(srv, chat)
+	go srv.Serve(lis)
+
+	cfg.Address = "bufnet"
+	cfg.Insecure = true
+	cfg.DialOptions = append(cfg.DialOptions, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		srv.Stop()
+	})
+	return c
+}
+
+func TestCredentials(t *testing.T) {
+	c := newTestClient(t, &flakyChat{}, Config{Token: BasicToken("roman", "pwd")})
+
+	resp, err := c.SayHello(context.Background(), &pb.Message{})
+	if err != nil {
+		t.Fatalf("SayHello: %v", err)
+	}
+	if resp.Body != "roman" {
+		t.Errorf("expected server to see user roman, got %q", resp.Body)
+	}
+
+	anonymous := newTestClient(t, &flakyChat{}, Config{})
+	if _, err := anonymous.SayHello(context.Background(), &pb.Message{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without token, got %v", err)
+	}
+}
+
+func TestRetryUnavailable(t *testing.T) {
+	chat := &flakyChat{failures: 2}
+	c := newTestClient(t, chat, Config{Token: BasicToken("roman", "pwd")})
+
+	if _, err := c.SayHello(context.Background(), &pb.Message{}); err != nil {
+		t.Fatalf("expected call to succeed after retries, got %v", err)
+	}
+	if calls := atomic.LoadInt32(&chat.calls); calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+
+	chat = &flakyChat{failures: 10}
+	c = newTestClient(t, chat, Config{Token: BasicToken("roman", "pwd")})
+	if _, err := c.SayHello(context.Background(), &pb.Message{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable after all attempts, got %v", err)
+	}
+	if calls := atomic.LoadInt32(&chat.calls); calls != 4 {
+		t.Errorf("expected 4 attempts, got %d", calls)
+	}
+}
+
+func TestDefaultDeadline(t *testing.T) {
+	c := newTestClient(t, &flakyChat{delay: time.Second}, Config{
+		Token:   BasicToken("roman", "pwd"),
+		Timeout: 50 * time.Millisecond,
+	})
+
+	start := time.Now()
+	_, err := c.SayHello(context.Background(), &pb.Message{})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("default deadline wasn't applied, call took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.SayHello(ctx, &pb.Message{}); err != nil {
+		t.Errorf("caller deadline must take precedence, got %v", err)
+	}
+}
@@ -1,29 +1,39 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 
+	"github.com/grpc-example/client"
+	"github.com/grpc-example/interceptors"
 	"github.com/grpc-example/pb"
-	"golang.org/x/net/context"
+	"github.com/grpc-example/tracing"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
 )
 
 func main() {
-	var conn *grpc.ClientConn
-	conn, err := grpc.Dial(":8080", grpc.WithInsecure())
+	addr := flag.String("addr", ":9090", "address of the ChatService server")
+	user := flag.String("user", "roman", "user name")
+	password := flag.String("password", "pwd", "user password")
+	flag.Parse()
+
+	tracer := interceptors.Tracing{Tracer: tracing.NewTracer(tracing.NewStdoutExporter())}
+	c, err := client.New(client.Config{
+		Address:  *addr,
+		Token:    client.BasicToken(*user, *password),
+		Insecure: true,
+		DialOptions: []grpc.DialOption{
+			grpc.WithChainUnaryInterceptor(tracer.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(tracer.StreamClientInterceptor()),
+		},
+	})
 	if err != nil {
 		log.Fatalf("did not connect: %s", err)
 	}
-	defer conn.Close()
-
-	c := pb.NewChatServiceClient(conn)
-
-	ctx := metadata.NewOutgoingContext(context.TODO(), metadata.New(map[string]string{
-		"Authorization": "Bearer cm9tYW46cHdk",
-	}))
+	defer c.Close()
 
-	response, err := c.SayHello(ctx, &pb.Message{
+	response, err := c.SayHello(context.Background(), &pb.Message{
 		Id:           1,
 		Body:         "Hello From Client!",
 		PhoneNumbers: []string{"111", "222"},
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/grpc-example/gateway"
+	"github.com/grpc-example/pb"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "HTTP address to listen on")
+	grpcAddr := flag.String("grpc", ":9090", "address of the ChatService gRPC server")
+	flag.Parse()
+
+	conn, err := grpc.Dial(*grpcAddr, grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("did not connect: %s", err)
+	}
+	defer conn.Close()
+
+	gw := gateway.New(pb.NewChatServiceClient(conn))
+	log.Printf("starting gateway on %s", *addr)
+
+	if err := http.ListenAndServe(*addr, gw); err != nil {
+		log.Fatalf("failed to serve: %s", err)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grpc-example/gateway"
+	"github.com/grpc-example/pb"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// The test binary links what the gateway binary links, the error details
+// are built by hand so they don't bring their types in.
+
+type failingChat struct {
+	pb.UnimplementedChatServiceServer
+	st *status.Status
+}
+
+func (c failingChat) SayHello(ctx context.Context, in *pb.Message) (*pb.Message, error) {
+	return nil, c.st.Err()
+}
+
+func detail(typeName string, fields ...[]byte) *anypb.Any {
+	var value []byte
+	for i, f := range fields {
+		value = protowire.AppendTag(value, protowire.Number(i+1), protowire.BytesType)
+		value = protowire.AppendBytes(value, f)
+	}
+	return &anypb.Any{TypeUrl: "type.googleapis.com/" + typeName, Value: value}
+}
+
+// entry is a map<string, string> entry.
+func entry(k, v string) []byte {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, k)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func TestErrorDetails(t *testing.T) {
+	delay, err := proto.Marshal(durationpb.New(1500 * 1e6))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		st      *spb.Status
+		code    int
+		details []map[string]interface{}
+	}{
+		{
+			name: "rate limit",
+			st: &spb.Status{
+				Code:    int32(codes.ResourceExhausted),
+				Message: "rate limit exceeded",
+				Details: []*anypb.Any{detail("google.rpc.RetryInfo", delay)},
+			},
+			code: http.StatusTooManyRequests,
+			details: []map[string]interface{}{
+				{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "1.500s"},
+			},
+		},
+		{
+			// the detail the Errors interceptor translates apperr errors with
+			name: "apperr",
+			st: &spb.Status{
+				Code:    int32(codes.InvalidArgument),
+				Message: "invalid task",
+				Details: []*anypb.Any{detail("google.rpc.ErrorInfo", []byte("InvalidArgument"), []byte("chat"), entry("alias", "is required"))},
+			},
+			code: http.StatusBadRequest,
+			details: []map[string]interface{}{
+				{
+					"@type":    "type.googleapis.com/google.rpc.ErrorInfo",
+					"reason":   "InvalidArgument",
+					"domain":   "chat",
+					"metadata": map[string]interface{}{"alias": "is required"},
+				},
+			},
+		},
+	} {
+		resp, body := call(t, status.FromProto(tt.st))
+		if resp.StatusCode != tt.code || resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected a JSON %d, got %d %s", tt.name, tt.code, resp.StatusCode, resp.Header.Get("Content-Type"))
+			continue
+		}
+
+		var got struct {
+			Code    int32
+			Message string
+			Details []map[string]interface{}
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("%s: can't decode the error %s: %v", tt.name, body, err)
+			continue
+		}
+		if got.Code != tt.st.Code || got.Message != tt.st.Message || !reflect.DeepEqual(got.Details, tt.details) {
+			t.Errorf("%s: unexpected error %s", tt.name, body)
+		}
+	}
+}
+
+// call sends SayHello through the gateway to a server failing with st.
+func call(t *testing.T, st *status.Status) (*http.Response, []byte) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterChatServiceServer(srv, failingChat{st: st})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	ts := httptest.NewServer(gateway.New(pb.NewChatServiceClient(conn)))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/v1/hello", "application/json", strings.NewReader(`{"body":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grpc-example/client"
+	"github.com/grpc-example/loadgen"
+	_ "github.com/grpc-example/pb"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "address of the server")
+	user := flag.String("user", "roman", "user name")
+	password := flag.String("password", "pwd", "user password")
+	rpc := flag.String("rpc", "SayHello", "ChatService method, or service/method of another service")
+	data := flag.String("data", `{"body":"load test","room":"loadgen"}`, "request message as JSON")
+	concurrency := flag.Int("c", 10, "number of concurrent workers")
+	qps := flag.Float64("qps", 0, "target calls per second across all workers, 0 means no limit")
+	duration := flag.Duration("d", 10*time.Second, "duration of the run, 0 means until -n calls are made")
+	requests := flag.Int("n", 0, "number of calls, 0 means until -d passes")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of a single call, long-lived streams end with it")
+	jsonPath := flag.String("json", "", "write the summary as JSON to this file, - for stdout")
+	flag.Parse()
+
+	if *duration == 0 && *requests == 0 {
+		log.Fatal("either -d or -n must be set")
+	}
+
+	method := *rpc
+	if !strings.Contains(method, "/") {
+		method = "chat.ChatService/" + method
+	}
+
+	c, err := client.New(client.Config{
+		Address:  *addr,
+		Token:    client.BasicToken(*user, *password),
+		Insecure: true,
+		Timeout:  *timeout,
+		// every failure should be counted, not hidden by retries
+		DialOptions: []grpc.DialOption{grpc.WithDisableRetry()},
+	})
+	if err != nil {
+		log.Fatalf("did not connect: %s", err)
+	}
+	defer c.Close()
+
+	call, err := loadgen.NewRPCCall(c.Conn(), method, *data, *timeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	summary := loadgen.Run(ctx, loadgen.Config{
+		Concurrency: *concurrency,
+		QPS:         *qps,
+		Duration:    *duration,
+		Requests:    *requests,
+	}, call)
+	summary.Method = method
+
+	if *jsonPath != "-" {
+		printSummary(summary)
+	}
+	if *jsonPath != "" {
+		if err := writeJSON(*jsonPath, summary); err != nil {
+			log.Fatalf("can't write summary: %v", err)
+		}
+	}
+}
+
+func printSummary(s loadgen.Summary) {
+	fmt.Printf("method:      %s\n", s.Method)
+	fmt.Printf("concurrency: %d, target qps: %g\n", s.Concurrency, s.TargetQPS)
+	fmt.Printf("requests:    %d in %.2fs, %.1f req/s, %d errors\n", s.Requests, s.Elapsed, s.Throughput, s.Errors)
+	fmt.Printf("latency ms:  min %.2f  mean %.2f  p50 %.2f  p90 %.2f  p95 %.2f  p99 %.2f  max %.2f\n",
+		s.Latency.Min, s.Latency.Mean, s.Latency.P50, s.Latency.P90, s.Latency.P95, s.Latency.P99, s.Latency.Max)
+
+	codes := make([]string, 0, len(s.Codes))
+	for code := range s.Codes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	fmt.Println("codes:")
+	for _, code := range codes {
+		fmt.Printf("  %-20s %d\n", code, s.Codes[code])
+	}
+}
+
+func writeJSON(path string, s loadgen.Summary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/grpc-example/pb"
+	"github.com/grpc-example/protocheck"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func main() {
+	protos := flag.String("protos", "protos", "directory with .proto sources")
+	baselinePath := flag.String("baseline", "protos/baseline.binpb", "committed baseline descriptor set")
+	update := flag.Bool("update", false, "write the current descriptors as the new baseline")
+	strict := flag.Bool("strict", false, "fail on JSON incompatible changes as well")
+	flag.Parse()
+
+	current, err := protocheck.ParseDir(*protos)
+	if err != nil {
+		log.Fatalf("can't parse protos: %v", err)
+	}
+
+	failed := false
+	for _, problem := range protocheck.CheckGenerated(current, protoregistry.GlobalFiles) {
+		fmt.Println(problem)
+		failed = true
+	}
+
+	if *update {
+		if err := protocheck.WriteDescriptorSet(*baselinePath, current); err != nil {
+			log.Fatalf("can't write baseline: %v", err)
+		}
+	} else {
+		baseline, err := protocheck.ReadDescriptorSet(*baselinePath)
+		if err != nil {
+			log.Fatalf("can't read baseline: %v", err)
+		}
+		for _, change := range protocheck.Compare(baseline, current) {
+			fmt.Println(change)
+			if change.Level == protocheck.Wire || *strict {
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -1,31 +1,105 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/grpc-example/directory"
 	"github.com/grpc-example/handler"
 	"github.com/grpc-example/interceptors"
 	"github.com/grpc-example/pb"
+	"github.com/grpc-example/presence"
+	"github.com/grpc-example/ratelimit"
+	"github.com/grpc-example/storage"
+	"github.com/grpc-example/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 )
 
 func main() {
+	dbPath := flag.String("db", "", "sqlite database file for message history, in-memory storage if empty")
+	metricsAddr := flag.String("metrics", ":9091", "address to serve prometheus metrics on")
+	trace := flag.Bool("trace", false, "write finished trace spans to stdout")
+	rateLimitPath := flag.String("ratelimit", "", "JSON file with per method rate limits")
+	flag.Parse()
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", 9090))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	var store storage.Storage = storage.NewMemory()
+	if *dbPath != "" {
+		store, err = storage.NewSQLite(*dbPath)
+		if err != nil {
+			log.Fatalf("failed to open storage: %v", err)
+		}
+	}
+	defer store.Close()
+
+	rateLimitConfig := interceptors.RateLimitConfig{Default: ratelimit.Limit{Rate: 10, Burst: 20}}
+	if *rateLimitPath != "" {
+		rateLimitConfig, err = interceptors.LoadRateLimitConfig(*rateLimitPath)
+		if err != nil {
+			log.Fatalf("failed to load rate limits: %v", err)
+		}
+	}
+
+	var exporter tracing.Exporter
+	if *trace {
+		exporter = tracing.NewStdoutExporter()
+	}
+	tracer := interceptors.Tracing{Tracer: tracing.NewTracer(exporter)}
+	accessLog := interceptors.NewAccessLog(os.Stdout)
+	metrics := interceptors.NewMetrics(prometheus.DefaultRegisterer)
 	authMD := interceptors.AuthMD{}
+	rateLimit := interceptors.RateLimit{Limiter: ratelimit.NewMemory(), Config: rateLimitConfig}
+	errs := interceptors.Errors{Domain: "grpc-example"}
+
+	// observability goes first so rejected calls are seen as well
 	opts := make([]grpc.ServerOption, 0)
-	opts = append(opts, grpc.ChainUnaryInterceptor(authMD.UnaryInterceptor()))
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		tracer.UnaryInterceptor(),
+		accessLog.UnaryInterceptor(),
+		metrics.UnaryInterceptor(),
+		authMD.UnaryInterceptor(),
+		rateLimit.UnaryInterceptor(),
+		errs.UnaryInterceptor(),
+	))
+	opts = append(opts, grpc.ChainStreamInterceptor(
+		tracer.StreamInterceptor(),
+		accessLog.StreamInterceptor(),
+		metrics.StreamInterceptor(),
+		authMD.StreamInterceptor(),
+		rateLimit.StreamInterceptor(),
+		errs.StreamInterceptor(),
+	))
 
 	grpcServer := grpc.NewServer(opts...)
 
-	chatHandler := handler.Chat{}
+	people := directory.NewMemory()
+	tracker := presence.NewTracker(presence.DefaultIdleTimeout, presence.DefaultTypingTimeout)
+	go tracker.Run(context.Background(), time.Second)
+
+	chatHandler := handler.Chat{Store: store, Directory: people, Presence: tracker}
+	peopleHandler := handler.People{Directory: people}
 	// registering specific handlers for this server
 	pb.RegisterChatServiceServer(grpcServer, &chatHandler)
+	pb.RegisterPersonServiceServer(grpcServer, &peopleHandler)
+	go func() {
+		http.Handle("/metrics", promhttp.Handler())
+		if err := http.ListenAndServe(*metricsAddr, nil); err != nil {
+			log.Printf("failed to serve metrics: %s", err)
+		}
+	}()
+
 	log.Println("starting server")
 
 	if err := grpcServer.Serve(lis); err != nil {
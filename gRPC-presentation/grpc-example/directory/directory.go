@@ -0,0 +1,28 @@
+// Package directory keeps the people known to the chat.
+package directory
+
+import (
+	"context"
+
+	"github.com/awnzl/workshops/first/apperr"
+	"github.com/grpc-example/pb"
+)
+
+// NotFound returns the error of a missing person, a new one per call as the
+// caller may add fields.
+func NotFound(id string) *apperr.Error {
+	return apperr.New(apperr.NotFound, "person not found").With("id", id)
+}
+
+// Directory stores people by id. Implementations must be safe for concurrent use
+// and must not keep or return references to the caller's messages.
+type Directory interface {
+	// Create stores p, assigning its id and created time, and returns the stored copy.
+	Create(ctx context.Context, p *pb.Person) (*pb.Person, error)
+	Get(ctx context.Context, id string) (*pb.Person, error)
+	// Update replaces the stored person with the same id.
+	Update(ctx context.Context, p *pb.Person) (*pb.Person, error)
+	Delete(ctx context.Context, id string) error
+	// List returns up to limit people with ids greater than afterID, ordered by id.
+	List(ctx context.Context, afterID string, limit int) ([]*pb.Person, error)
+}
@@ -0,0 +1,107 @@
+package directory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grpc-example/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Memory struct {
+	mu     sync.RWMutex
+	people map[string]*pb.Person
+	// ids are kept sorted for listing
+	ids []string
+}
+
+func NewMemory() *Memory {
+	return &Memory{people: make(map[string]*pb.Person)}
+}
+
+func (m *Memory) Create(ctx context.Context, p *pb.Person) (*pb.Person, error) {
+	stored := proto.Clone(p).(*pb.Person)
+	stored.Created = timestamppb.New(time.Now().UTC())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		stored.Id = newID()
+		if _, ok := m.people[stored.Id]; !ok {
+			break
+		}
+	}
+	m.people[stored.Id] = stored
+
+	i := sort.SearchStrings(m.ids, stored.Id)
+	m.ids = append(m.ids, "")
+	copy(m.ids[i+1:], m.ids[i:])
+	m.ids[i] = stored.Id
+
+	return proto.Clone(stored).(*pb.Person), nil
+}
+
+func (m *Memory) Get(ctx context.Context, id string) (*pb.Person, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.people[id]
+	if !ok {
+		return nil, NotFound(id)
+	}
+	return proto.Clone(p).(*pb.Person), nil
+}
+
+func (m *Memory) Update(ctx context.Context, p *pb.Person) (*pb.Person, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.people[p.Id]; !ok {
+		return nil, NotFound(p.Id)
+	}
+	stored := proto.Clone(p).(*pb.Person)
+	m.people[p.Id] = stored
+	return proto.Clone(stored).(*pb.Person), nil
+}
+
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.people[id]; !ok {
+		return NotFound(id)
+	}
+	delete(m.people, id)
+
+	i := sort.SearchStrings(m.ids, id)
+	m.ids = append(m.ids[:i], m.ids[i+1:]...)
+	return nil
+}
+
+func (m *Memory) List(ctx context.Context, afterID string, limit int) ([]*pb.Person, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := sort.SearchStrings(m.ids, afterID)
+	if i < len(m.ids) && m.ids[i] == afterID {
+		i++
+	}
+
+	res := make([]*pb.Person, 0)
+	for ; i < len(m.ids) && (limit <= 0 || len(res) < limit); i++ {
+		res = append(res, proto.Clone(m.people[m.ids[i]]).(*pb.Person))
+	}
+	return res, nil
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
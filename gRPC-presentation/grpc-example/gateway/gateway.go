@@ -0,0 +1,222 @@
+// Package gateway exposes ChatService over HTTP/JSON in the grpc-gateway manner:
+// requests and responses are protojson encoded messages, the Authorization
+// header is forwarded as gRPC metadata and server streams are sent as
+// Server-Sent Events.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grpc-example/pb"
+	// registers the google.rpc error details, statuses carrying them can't
+	// be encoded otherwise
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	authHeader       = "authorization"
+	retryAfterHeader = "retry-after"
+)
+
+var (
+	marshaler   = protojson.MarshalOptions{}
+	unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+type Gateway struct {
+	client pb.ChatServiceClient
+	mux    *http.ServeMux
+}
+
+func New(client pb.ChatServiceClient) *Gateway {
+	g := &Gateway{
+		client: client,
+		mux:    http.NewServeMux(),
+	}
+	g.mux.HandleFunc("/v1/hello", g.sayHello)
+	g.mux.HandleFunc("/v1/history", g.history)
+	g.mux.HandleFunc("/v1/presence", g.watchPresence)
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// sayHello maps POST /v1/hello with a Message body to ChatService.SayHello.
+func (g *Gateway) sayHello(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, "can't read body"))
+		return
+	}
+	in := &pb.Message{}
+	if err := unmarshaler.Unmarshal(body, in); err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "can't decode message: %v", err))
+		return
+	}
+
+	var trailer metadata.MD
+	out, err := g.client.SayHello(outgoingContext(r), in, grpc.Trailer(&trailer))
+	if err != nil {
+		if values := trailer.Get(retryAfterHeader); len(values) > 0 {
+			w.Header().Set("Retry-After", values[0])
+		}
+		writeError(w, err)
+		return
+	}
+	writeMessage(w, out)
+}
+
+// history maps GET /v1/history?room=&from=&to=&page_size=&page_token= to
+// ChatService.History, every received page is sent as a separate event.
+func (g *Gateway) history(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	in, err := historyRequest(r)
+	if err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	stream, err := g.client.History(outgoingContext(r), in)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	serveEvents(w, stream, func() (proto.Message, error) {
+		return stream.Recv()
+	})
+}
+
+// watchPresence maps GET /v1/presence?room= to ChatService.WatchPresence.
+func (g *Gateway) watchPresence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	in := &pb.WatchPresenceRequest{Room: r.URL.Query().Get("room")}
+	stream, err := g.client.WatchPresence(outgoingContext(r), in)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	serveEvents(w, stream, func() (proto.Message, error) {
+		return stream.Recv()
+	})
+}
+
+func historyRequest(r *http.Request) (*pb.HistoryRequest, error) {
+	query := r.URL.Query()
+	in := &pb.HistoryRequest{
+		Room:      query.Get("room"),
+		PageToken: query.Get("page_token"),
+	}
+
+	if v := query.Get("page_size"); v != "" {
+		size, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page_size: %v", err)
+		}
+		in.PageSize = uint32(size)
+	}
+
+	for name, ts := range map[string]**timestamppb.Timestamp{"from": &in.From, "to": &in.To} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		*ts = timestamppb.New(t)
+	}
+	return in, nil
+}
+
+// outgoingContext carries the Authorization header into gRPC metadata,
+// so the server side interceptors see the same credentials.
+func outgoingContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authHeader, auth)
+	}
+	return ctx
+}
+
+func writeMessage(w http.ResponseWriter, m proto.Message) {
+	data, err := marshaler.Marshal(m)
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "can't encode response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	data, merr := marshaler.Marshal(st.Proto())
+	if merr != nil {
+		log.Printf("can't encode status %v: %v", st, merr)
+		http.Error(w, st.Message(), HTTPStatusFromCode(st.Code()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatusFromCode(st.Code()))
+	w.Write(data)
+}
+
+// HTTPStatusFromCode converts a gRPC code into the corresponding HTTP response status,
+// following google.golang.org/genproto/googleapis/rpc/code.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
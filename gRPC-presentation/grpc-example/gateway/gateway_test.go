@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-example/handler"
+	"github.com/grpc-example/interceptors"
+	"github.com/grpc-example/pb"
+	"github.com/grpc-example/presence"
+	"github.com/grpc-example/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const authorization = "Bearer cm9tYW46cHdk" // roman:pwd
+
+func newTestGateway(t *testing.T) *httptest.Server {
+	lis := bufconn.Listen(1 << 20)
+	authMD := interceptors.AuthMD{}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authMD.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(authMD.StreamInterceptor()),
+	)
+	pb.RegisterChatServiceServer(srv, &handler.Chat{
+		Store:    storage.NewMemory(),
+		Presence: presence.NewTracker(presence.DefaultIdleTimeout, presence.DefaultTypingTimeout),
+	})
+	go srv.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	ts := httptest.NewServer(New(pb.NewChatServiceClient(conn)))
+	t.Cleanup(func() {
+		ts.Close()
+		conn.Close()
+		srv.Stop()
+	})
+	return ts
+}
+
+func post(t *testing.T, url, auth, body string) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return do(t, req)
+}
+
+func do(t *testing.T, req *http.Request) (*http.Response, string) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		sb.WriteString(sc.Text())
+		sb.WriteString("\n")
+	}
+	return resp, sb.String()
+}
+
+func TestSayHello(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp, body := post(t, ts.URL+"/v1/hello", authorization,
+		`{"body":"hi","room":"go","personInfo":{"name":"Roman","lastName":"Kosyi"}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	out := &pb.Message{}
+	if err := protojson.Unmarshal([]byte(body), out); err != nil {
+		t.Fatalf("can't decode response %s: %v", body, err)
+	}
+	if out.Body != "Hello From roman the Server:ChatHandler!" || out.Id == 0 {
+		t.Errorf("unexpected response: %v", out)
+	}
+
+	resp, body = post(t, ts.URL+"/v1/hello", "", `{"body":"hi"}`)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without Authorization, got %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = post(t, ts.URL+"/v1/hello", authorization, `{"body":`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for broken JSON, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestHistoryEvents(t *testing.T) {
+	ts := newTestGateway(t)
+
+	for _, b := range []string{"one", "two", "three"} {
+		resp, body := post(t, ts.URL+"/v1/hello", authorization, `{"room":"go","body":"`+b+`"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/v1/history?room=go&page_size=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, body := do(t, req)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected event stream, got %q", ct)
+	}
+	var bodies []string
+	pages := 0
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		page := &pb.HistoryPage{}
+		if err := protojson.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), page); err != nil {
+			t.Fatalf("can't decode event %q: %v", line, err)
+		}
+		pages++
+		for _, m := range page.Messages {
+			bodies = append(bodies, m.Body)
+		}
+	}
+	if pages != 2 || strings.Join(bodies, ",") != "one,two,three" {
+		t.Errorf("expected 2 pages with one,two,three, got %d pages with %v", pages, bodies)
+	}
+
+	req.Header.Del("Authorization")
+	resp, body = do(t, req)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without Authorization, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestPresenceEvents(t *testing.T) {
+	ts := newTestGateway(t)
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/v1/presence?room=go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", authorization)
+
+	// the response starts before anybody is online
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	post(t, ts.URL+"/v1/hello", authorization, `{"room":"go","status":"Typing"}`)
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		ev := &pb.PresenceEvent{}
+		if err := protojson.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), ev); err != nil {
+			t.Fatalf("can't decode event %q: %v", line, err)
+		}
+		if ev.User != "roman" || ev.Status != pb.Message_Typing {
+			t.Errorf("unexpected event %v", ev)
+		}
+		return
+	}
+	t.Fatalf("stream ended without events: %v", sc.Err())
+}
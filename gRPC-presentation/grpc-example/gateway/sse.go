@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// serveEvents writes every message returned by recv as a Server-Sent Event
+// until the stream ends. A failed stream is reported with an "error" event,
+// because the response status is already sent by then.
+func serveEvents(w http.ResponseWriter, stream grpc.ClientStream, recv func() (proto.Message, error)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Header metadata comes either with the first message, or earlier if the
+	// server sends it explicitly, then the events can start before any message.
+	// Without metadata the first message decides between an event stream and
+	// an error response.
+	var m proto.Message
+	md, err := stream.Header()
+	received := err == nil && len(md) == 0
+	if received {
+		m, err = recv()
+	}
+	if err != nil && err != io.EOF {
+		// nothing is sent yet, so a regular error response can be used
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if !received {
+		m, err = recv()
+	}
+	for ; err == nil; m, err = recv() {
+		if werr := writeEvent(w, "message", m); werr != nil {
+			log.Printf("can't write event: %v", werr)
+			return
+		}
+		flusher.Flush()
+	}
+
+	if err != io.EOF {
+		if werr := writeEvent(w, "error", status.Convert(err).Proto()); werr != nil {
+			log.Printf("can't write event: %v", werr)
+		}
+	}
+	flusher.Flush()
+}
+
+func writeEvent(w io.Writer, event string, m proto.Message) error {
+	data, err := marshaler.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
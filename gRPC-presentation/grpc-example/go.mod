@@ -4,11 +4,20 @@ go 1.16
 
 require (
 	github.com/HdrHistogram/hdrhistogram-go v1.1.0 // indirect
+	github.com/awnzl/workshops/first v0.0.0
 	github.com/envoyproxy/protoc-gen-validate v0.6.1
 	github.com/golang/protobuf v1.5.2
 	github.com/goodsign/monday v1.0.0
-	google.golang.org/grpc v1.39.0
+	github.com/jhump/protoreflect v1.11.0
+	github.com/mattn/go-sqlite3 v1.14.8
+	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
+	golang.org/x/net v0.0.0-20200822124328-c89045814202
+	google.golang.org/genproto v0.0.0-20200806141610-86f49bd18e98
+	google.golang.org/grpc v1.42.0
 	google.golang.org/grpc/cmd/protoc-gen-go-grpc v1.1.0 // indirect
 	google.golang.org/grpc/examples v0.0.0-20210726200256-00edd8c13a7a // indirect
 	google.golang.org/protobuf v1.26.0
 )
+
+replace github.com/awnzl/workshops/first => ../../first
@@ -4,22 +4,158 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
+	"github.com/grpc-example/directory"
+	"github.com/grpc-example/interceptors"
 	"github.com/grpc-example/pb"
+	"github.com/grpc-example/presence"
+	"github.com/grpc-example/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// PresenceSnapshotHeader is the WatchPresence header with the number of
+// events describing the current state, which are sent before the changes.
+const PresenceSnapshotHeader = "presence-snapshot"
+
 type Chat struct {
 	pb.UnimplementedChatServiceServer
+
+	Store storage.Storage
+	// Directory resolves person_info of received messages by id, if set.
+	Directory directory.Directory
+	// Presence is updated with the status of received messages, if set.
+	Presence *presence.Tracker
 }
 
 func (s *Chat) SayHello(ctx context.Context, in *pb.Message) (*pb.Message, error) {
-	log.Printf("Receive message from client: %v", in)
+	person, err := resolvePerson(ctx, s.Directory, in.PersonInfo)
+	if err != nil {
+		return nil, err
+	}
+	if person != in.PersonInfo {
+		in = proto.Clone(in).(*pb.Message)
+		in.PersonInfo = person
+	}
+
+	stored, err := s.Store.Save(ctx, in)
+	if err != nil {
+		log.Printf("can't store message: %v", err)
+		return nil, status.Error(codes.Internal, "can't store message")
+	}
+
+	user, _ := interceptors.UserFromContext(ctx)
+	if s.Presence != nil {
+		s.Presence.Update(user, in.Room, in.Status)
+	}
 
-	user, _ := ctx.Value("user").(string)
 	return &pb.Message{
-		LastUpdated: timestamppb.New(time.Now().UTC()),
-		Body: fmt.Sprintf("Hello From %s the Server:ChatHandler!", user),
-		}, nil
-}
\ No newline at end of file
+		Id:          stored.Id,
+		Room:        stored.Room,
+		LastUpdated: stored.LastUpdated,
+		Body:        fmt.Sprintf("Hello From %s the Server:ChatHandler!", user),
+	}, nil
+}
+
+// History streams stored messages of a room page by page, starting after
+// the page token if one is given.
+func (s *Chat) History(in *pb.HistoryRequest, stream pb.ChatService_HistoryServer) error {
+	var afterID uint64
+	cursor, err := decodePageToken(in.PageToken)
+	if err == nil && cursor != "" {
+		afterID, err = strconv.ParseUint(cursor, 10, 32)
+	}
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid page token")
+	}
+
+	size := pageSize(in.PageSize)
+
+	q := storage.Query{
+		Room:    in.Room,
+		AfterID: uint32(afterID),
+		// one extra message tells whether there is a next page
+		Limit: size + 1,
+	}
+	if in.From != nil {
+		q.From = in.From.AsTime()
+	}
+	if in.To != nil {
+		q.To = in.To.AsTime()
+	} else {
+		q.To = time.Now().UTC()
+	}
+
+	for {
+		messages, err := s.Store.List(stream.Context(), q)
+		if err != nil {
+			log.Printf("can't list messages: %v", err)
+			return status.Error(codes.Internal, "can't list messages")
+		}
+
+		page := &pb.HistoryPage{Messages: messages}
+		if len(messages) > size {
+			page.Messages = messages[:size]
+			q.AfterID = page.Messages[size-1].Id
+			page.NextPageToken = encodePageToken(strconv.FormatUint(uint64(q.AfterID), 10))
+		}
+
+		if err := stream.Send(page); err != nil {
+			return err
+		}
+		if page.NextPageToken == "" {
+			return nil
+		}
+	}
+}
+
+// WatchPresence streams the presence of users in a room, or in all rooms if
+// no room is given: the current state first, then the changes.
+func (s *Chat) WatchPresence(in *pb.WatchPresenceRequest, stream pb.ChatService_WatchPresenceServer) error {
+	if s.Presence == nil {
+		return status.Error(codes.Unimplemented, "presence is disabled")
+	}
+
+	snapshot, events, cancel := s.Presence.Subscribe(in.Room)
+	defer cancel()
+
+	// lets the client know the subscription is active even if nobody is online
+	// and how many of the first events are the current state
+	if err := stream.SendHeader(metadata.Pairs(PresenceSnapshotHeader, strconv.Itoa(len(snapshot)))); err != nil {
+		return err
+	}
+
+	for _, ev := range snapshot {
+		if err := stream.Send(presenceEvent(ev)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case ev, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "presence stream fell behind, subscribe again")
+			}
+			if err := stream.Send(presenceEvent(ev)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func presenceEvent(ev presence.Event) *pb.PresenceEvent {
+	return &pb.PresenceEvent{
+		User:   ev.User,
+		Room:   ev.Room,
+		Status: ev.Status,
+		At:     timestamppb.New(ev.At),
+	}
+}
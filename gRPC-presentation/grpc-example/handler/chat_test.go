@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grpc-example/pb"
+	"github.com/grpc-example/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// historyStream collects the pages sent by History.
+type historyStream struct {
+	grpc.ServerStream
+	pages []*pb.HistoryPage
+}
+
+func (s *historyStream) Context() context.Context { return context.Background() }
+
+func (s *historyStream) Send(page *pb.HistoryPage) error {
+	s.pages = append(s.pages, page)
+	return nil
+}
+
+// pages are the sizes of the pages of n messages.
+func pages(n, size int) []int {
+	var sizes []int
+	for ; n > 0; n -= size {
+		if n < size {
+			size = n
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	chat := &Chat{Store: store}
+
+	for i := 0; i < 1100; i++ {
+		room := "go"
+		if i%2 == 1 {
+			room = "rust"
+		}
+		if _, err := store.Save(ctx, &pb.Message{Room: room}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if _, err := store.Save(ctx, &pb.Message{Room: "small"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		req   *pb.HistoryRequest
+		pages []int
+	}{
+		{"empty room", &pb.HistoryRequest{Room: "nobody"}, []int{0}},
+		{"single page", &pb.HistoryRequest{Room: "small"}, []int{1}},
+		{"default size", &pb.HistoryRequest{Room: "go"}, pages(550, 50)},
+		{"page size", &pb.HistoryRequest{Room: "rust", PageSize: 120}, []int{120, 120, 120, 120, 70}},
+		{"exact pages", &pb.HistoryRequest{Room: "rust", PageSize: 110}, pages(550, 110)},
+		{"max size", &pb.HistoryRequest{Room: "go", PageSize: 1000}, []int{maxPageSize, 50}},
+		{"to before the first message", &pb.HistoryRequest{Room: "go", To: timestamppb.New(timestamppb.Now().AsTime().AddDate(-1, 0, 0))}, []int{0}},
+	} {
+		stream := &historyStream{}
+		if err := chat.History(tt.req, stream); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(stream.pages) != len(tt.pages) {
+			t.Errorf("%s: expected %d pages, got %d", tt.name, len(tt.pages), len(stream.pages))
+			continue
+		}
+		var lastID uint32
+		for i, page := range stream.pages {
+			if len(page.Messages) != tt.pages[i] {
+				t.Errorf("%s: expected %d messages on page %d, got %d", tt.name, tt.pages[i], i, len(page.Messages))
+			}
+			for _, msg := range page.Messages {
+				if msg.Room != tt.req.Room || msg.Id <= lastID {
+					t.Errorf("%s: unexpected message %v after id %d", tt.name, msg, lastID)
+				}
+				lastID = msg.Id
+			}
+			if last := i == len(stream.pages)-1; last != (page.NextPageToken == "") {
+				t.Errorf("%s: page %d has next page token %q", tt.name, i, page.NextPageToken)
+			}
+		}
+	}
+}
+
+func TestHistoryPageToken(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	chat := &Chat{Store: store}
+
+	for i := 0; i < 5; i++ {
+		if _, err := store.Save(ctx, &pb.Message{Room: "go"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	first := &historyStream{}
+	if err := chat.History(&pb.HistoryRequest{Room: "go", PageSize: 2}, first); err != nil {
+		t.Fatalf("History: %v", err)
+	}
+
+	// a token of the first page resumes from its last message
+	resumed := &historyStream{}
+	if err := chat.History(&pb.HistoryRequest{Room: "go", PageSize: 2, PageToken: first.pages[0].NextPageToken}, resumed); err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(resumed.pages) != 2 || resumed.pages[0].Messages[0].Id != 3 {
+		t.Errorf("expected to resume at message 3, got %v", resumed.pages)
+	}
+
+	for _, token := range []string{"not base64!", encodePageToken("x")} {
+		err := chat.History(&pb.HistoryRequest{Room: "go", PageToken: token}, &historyStream{})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("token %q: expected InvalidArgument, got %v", token, err)
+		}
+	}
+}
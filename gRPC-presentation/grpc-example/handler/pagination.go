@@ -0,0 +1,28 @@
+package handler
+
+import "encoding/base64"
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+func pageSize(requested uint32) int {
+	if requested == 0 {
+		return defaultPageSize
+	}
+	if requested > maxPageSize {
+		return maxPageSize
+	}
+	return int(requested)
+}
+
+// Page tokens are opaque for clients, inside it's the cursor of the last returned item.
+func encodePageToken(cursor string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
+
+func decodePageToken(token string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	return string(raw), err
+}
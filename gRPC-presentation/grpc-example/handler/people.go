@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/awnzl/workshops/first/apperr"
+	"github.com/grpc-example/directory"
+	"github.com/grpc-example/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const maxNameLength = 64
+
+var phoneNumberRe = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{2,19}$`)
+
+// immutablePersonFields are set by the server only.
+var immutablePersonFields = map[string]bool{"id": true, "created": true}
+
+type People struct {
+	pb.UnimplementedPersonServiceServer
+
+	Directory directory.Directory
+}
+
+func (s *People) CreatePerson(ctx context.Context, in *pb.Person) (*pb.Person, error) {
+	if err := validatePerson(in); err != nil {
+		return nil, err
+	}
+	p, err := s.Directory.Create(ctx, in)
+	if err != nil {
+		return nil, directoryError(err)
+	}
+	return p, nil
+}
+
+func (s *People) GetPerson(ctx context.Context, in *pb.GetPersonRequest) (*pb.Person, error) {
+	p, err := s.Directory.Get(ctx, in.Id)
+	if err != nil {
+		return nil, directoryError(err)
+	}
+	return p, nil
+}
+
+// UpdatePerson overwrites the fields listed in the update mask,
+// all mutable fields are overwritten without a mask.
+func (s *People) UpdatePerson(ctx context.Context, in *pb.UpdatePersonRequest) (*pb.Person, error) {
+	if in.Person == nil {
+		return nil, status.Error(codes.InvalidArgument, "person is required")
+	}
+
+	paths, err := updatePaths(in)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.Directory.Get(ctx, in.Person.Id)
+	if err != nil {
+		return nil, directoryError(err)
+	}
+
+	dst, src := p.ProtoReflect(), in.Person.ProtoReflect()
+	for _, path := range paths {
+		fd := dst.Descriptor().Fields().ByName(protoreflect.Name(path))
+		if src.Has(fd) {
+			dst.Set(fd, src.Get(fd))
+		} else {
+			dst.Clear(fd)
+		}
+	}
+
+	if err := validatePerson(p); err != nil {
+		return nil, err
+	}
+	p, err = s.Directory.Update(ctx, p)
+	if err != nil {
+		return nil, directoryError(err)
+	}
+	return p, nil
+}
+
+func (s *People) DeletePerson(ctx context.Context, in *pb.DeletePersonRequest) (*emptypb.Empty, error) {
+	if err := s.Directory.Delete(ctx, in.Id); err != nil {
+		return nil, directoryError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *People) ListPeople(ctx context.Context, in *pb.ListPeopleRequest) (*pb.ListPeopleResponse, error) {
+	afterID, err := decodePageToken(in.PageToken)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+
+	size := pageSize(in.PageSize)
+	// one extra person tells whether there is a next page
+	people, err := s.Directory.List(ctx, afterID, size+1)
+	if err != nil {
+		return nil, directoryError(err)
+	}
+
+	resp := &pb.ListPeopleResponse{People: people}
+	if len(people) > size {
+		resp.People = people[:size]
+		resp.NextPageToken = encodePageToken(resp.People[size-1].Id)
+	}
+	return resp, nil
+}
+
+// updatePaths returns the top level Person fields the update request is allowed to change.
+func updatePaths(in *pb.UpdatePersonRequest) ([]string, error) {
+	fields := in.Person.ProtoReflect().Descriptor().Fields()
+
+	if len(in.UpdateMask.GetPaths()) == 0 {
+		paths := make([]string, 0, fields.Len())
+		for i := 0; i < fields.Len(); i++ {
+			if name := string(fields.Get(i).Name()); !immutablePersonFields[name] {
+				paths = append(paths, name)
+			}
+		}
+		return paths, nil
+	}
+
+	if !in.UpdateMask.IsValid(in.Person) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid update mask %v", in.UpdateMask.GetPaths())
+	}
+	in.UpdateMask.Normalize()
+	for _, path := range in.UpdateMask.Paths {
+		if fields.ByName(protoreflect.Name(path)) == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "only whole fields can be updated, got %q", path)
+		}
+		if immutablePersonFields[path] {
+			return nil, status.Errorf(codes.InvalidArgument, "field %q can't be updated", path)
+		}
+	}
+	return in.UpdateMask.Paths, nil
+}
+
+func validatePerson(p *pb.Person) error {
+	var violations []string
+	if p.Name == "" {
+		violations = append(violations, "name is required")
+	}
+	if utf8.RuneCountInString(p.Name) > maxNameLength {
+		violations = append(violations, fmt.Sprintf("name is longer than %d characters", maxNameLength))
+	}
+	if utf8.RuneCountInString(p.LastName) > maxNameLength {
+		violations = append(violations, fmt.Sprintf("last_name is longer than %d characters", maxNameLength))
+	}
+	if p.Email != "" {
+		if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+			violations = append(violations, fmt.Sprintf("email %q is invalid", p.Email))
+		}
+	}
+	for i, phone := range p.Phones {
+		if !phoneNumberRe.MatchString(phone.Number) {
+			violations = append(violations, fmt.Sprintf("phones[%d].number %q is invalid", i, phone.Number))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return status.Errorf(codes.InvalidArgument, "invalid person: %v", violations)
+}
+
+// directoryError returns the apperr errors of the directory as they are, for
+// the Errors interceptor to translate, and hides the others.
+func directoryError(err error) error {
+	if apperr.CodeOf(err) != apperr.Unknown {
+		return err
+	}
+	log.Printf("directory error: %v", err)
+	return status.Error(codes.Internal, "directory error")
+}
+
+// resolvePerson replaces the person reference in a message with the directory entry.
+func resolvePerson(ctx context.Context, dir directory.Directory, ref *pb.Person) (*pb.Person, error) {
+	if dir == nil || ref.GetId() == "" {
+		return ref, nil
+	}
+	p, err := dir.Get(ctx, ref.Id)
+	if apperr.CodeOf(err) == apperr.NotFound {
+		return nil, apperr.Wrap(err, apperr.InvalidArgument, "unknown person").With("id", ref.Id)
+	}
+	if err != nil {
+		return nil, directoryError(err)
+	}
+	return p, nil
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awnzl/workshops/first/apperr"
+	"github.com/grpc-example/directory"
+	"github.com/grpc-example/pb"
+	"github.com/grpc-example/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestPeopleCRUD(t *testing.T) {
+	ctx := context.Background()
+	s := &People{Directory: directory.NewMemory()}
+
+	created, err := s.CreatePerson(ctx, &pb.Person{
+		Id:     "mine",
+		Name:   "Roman",
+		Email:  "roman@example.com",
+		Phones: []*pb.Person_PhoneNumber{{Number: "+380 (44) 111-22-33", Type: pb.Person_Work}},
+	})
+	if err != nil {
+		t.Fatalf("CreatePerson: %v", err)
+	}
+	if created.Id == "" || created.Id == "mine" || created.Created == nil {
+		t.Errorf("id and created must be assigned by the server, got %v", created)
+	}
+
+	updated, err := s.UpdatePerson(ctx, &pb.UpdatePersonRequest{
+		Person:     &pb.Person{Id: created.Id, LastName: "Kosyi", Email: "ignored@example.com"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"last_name"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePerson: %v", err)
+	}
+	if updated.Name != "Roman" || updated.LastName != "Kosyi" || updated.Email != "roman@example.com" {
+		t.Errorf("only last_name should change, got %v", updated)
+	}
+	if !updated.Created.AsTime().Equal(created.Created.AsTime()) {
+		t.Errorf("created changed on update: %v -> %v", created.Created, updated.Created)
+	}
+
+	got, err := s.GetPerson(ctx, &pb.GetPersonRequest{Id: created.Id})
+	if err != nil || got.LastName != "Kosyi" {
+		t.Errorf("GetPerson returned %v, %v", got, err)
+	}
+
+	if _, err := s.DeletePerson(ctx, &pb.DeletePersonRequest{Id: created.Id}); err != nil {
+		t.Fatalf("DeletePerson: %v", err)
+	}
+	// the Errors interceptor translates the apperr errors
+	_, err = s.GetPerson(ctx, &pb.GetPersonRequest{Id: created.Id})
+	if pub := apperr.Public(err); pub.Code != apperr.NotFound || pub.Fields["id"] != created.Id {
+		t.Errorf("expected NotFound with the id after delete, got %v", err)
+	}
+}
+
+func TestPeopleValidation(t *testing.T) {
+	ctx := context.Background()
+	s := &People{Directory: directory.NewMemory()}
+
+	for _, p := range []*pb.Person{
+		{},
+		{Name: "Roman", Email: "not an email"},
+		{Name: "Roman", Phones: []*pb.Person_PhoneNumber{{Number: "call me"}}},
+	} {
+		if _, err := s.CreatePerson(ctx, p); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for %v, got %v", p, err)
+		}
+	}
+
+	p, err := s.CreatePerson(ctx, &pb.Person{Name: "Roman"})
+	if err != nil {
+		t.Fatalf("CreatePerson: %v", err)
+	}
+	for _, mask := range [][]string{{"created"}, {"phones.number"}, {"unknown"}, {"name"}} {
+		_, err := s.UpdatePerson(ctx, &pb.UpdatePersonRequest{
+			Person:     &pb.Person{Id: p.Id},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: mask},
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for mask %v, got %v", mask, err)
+		}
+	}
+}
+
+func TestListPeoplePages(t *testing.T) {
+	ctx := context.Background()
+	s := &People{Directory: directory.NewMemory()}
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := s.CreatePerson(ctx, &pb.Person{Name: name}); err != nil {
+			t.Fatalf("CreatePerson: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	req := &pb.ListPeopleRequest{PageSize: 2}
+	for pages := 1; ; pages++ {
+		resp, err := s.ListPeople(ctx, req)
+		if err != nil {
+			t.Fatalf("ListPeople: %v", err)
+		}
+		for _, p := range resp.People {
+			if seen[p.Id] {
+				t.Errorf("person %v is listed twice", p)
+			}
+			seen[p.Id] = true
+		}
+		if resp.NextPageToken == "" {
+			if pages != 3 {
+				t.Errorf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected 5 people, got %d", len(seen))
+	}
+}
+
+func TestChatResolvesPerson(t *testing.T) {
+	ctx := context.Background()
+	dir := directory.NewMemory()
+	store := storage.NewMemory()
+	chat := &Chat{Store: store, Directory: dir}
+
+	p, err := dir.Create(ctx, &pb.Person{Name: "Roman", LastName: "Kosyi", Email: "roman@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := chat.SayHello(ctx, &pb.Message{Room: "go", PersonInfo: &pb.Person{Id: p.Id}}); err != nil {
+		t.Fatalf("SayHello: %v", err)
+	}
+	messages, err := store.List(ctx, storage.Query{Room: "go"})
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("List returned %v, %v", messages, err)
+	}
+	if messages[0].PersonInfo.GetEmail() != "roman@example.com" {
+		t.Errorf("person_info wasn't resolved: %v", messages[0].PersonInfo)
+	}
+
+	if _, err := chat.SayHello(ctx, &pb.Message{PersonInfo: &pb.Person{Id: "unknown"}}); apperr.CodeOf(err) != apperr.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unknown person, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package interceptors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/awnzl/workshops/first/apperr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors translates apperr errors returned by handlers into gRPC statuses
+// carrying an ErrorInfo detail with the error fields. It must be the last
+// interceptor of the chain, so the others see the translated status.
+type Errors struct {
+	// Domain is the ErrorInfo domain, e.g. the service name.
+	Domain string
+}
+
+func (e *Errors) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		resp, err = handler(ctx, req)
+		return resp, e.Status(info.FullMethod, err)
+	}
+}
+
+func (e *Errors) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return e.Status(info.FullMethod, handler(srv, ss))
+	}
+}
+
+// Status returns the gRPC status error for an apperr error, logging the
+// whole error as only its public part reaches the client. Other errors
+// are returned unchanged.
+func (e *Errors) Status(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var ae *apperr.Error
+	if !errors.As(err, &ae) {
+		return err
+	}
+
+	if data, merr := json.Marshal(ae); merr == nil {
+		log.Printf("%s: %s", method, data)
+	} else {
+		log.Printf("%s: %v", method, err)
+	}
+
+	pub := apperr.Public(err)
+	st := status.New(codes.Code(pub.Code.GRPCCode()), pub.Message)
+	info := &errdetails.ErrorInfo{Reason: pub.Code.String(), Domain: e.Domain}
+	if len(pub.Fields) > 0 {
+		info.Metadata = make(map[string]string, len(pub.Fields))
+		for k, v := range pub.Fields {
+			info.Metadata[k] = fmt.Sprint(v)
+		}
+	}
+	if detailed, derr := st.WithDetails(info); derr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,45 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/awnzl/workshops/first/apperr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrors(t *testing.T) {
+	interceptor := (&Errors{Domain: "chat"}).UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/chat.ChatService/SayHello"}
+	call := func(err error) error {
+		_, got := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+		return got
+	}
+
+	err := call(fmt.Errorf("say hello: %w",
+		apperr.Wrap(errors.New("db is down"), apperr.NotFound, "person not found").With("id", 42)))
+	st := status.Convert(err)
+	if st.Code() != codes.NotFound || st.Message() != "person not found" {
+		t.Errorf("expected NotFound without the cause, got %v", st)
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("expected ErrorInfo, got %v", st.Details())
+	}
+	ei, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	if !ok || ei.Reason != "NOT_FOUND" || ei.Domain != "chat" || ei.Metadata["id"] != "42" {
+		t.Errorf("unexpected details %v", st.Details()[0])
+	}
+
+	for _, want := range []error{nil, status.Error(codes.Aborted, "aborted"), errors.New("plain")} {
+		if got := call(want); got != want {
+			t.Errorf("expected %v unchanged, got %v", want, got)
+		}
+	}
+}
@@ -16,28 +16,54 @@ type AuthMD struct{}
 const (
 	authHeader = "authorization"
 	bearerAuth = "bearer"
+
+	// userKey is the context key the authenticated user name is stored under.
+	userKey = "user"
 )
 
+// UserFromContext returns the user name AuthMD extracted from credentials.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey).(string)
+	return user, ok
+}
+
 func (a *AuthMD) UnaryInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		credentials := a.getAuthCredentials(ctx)
-		if credentials == "" {
-			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		ctx, err = a.authenticate(ctx)
+		if err != nil {
+			return nil, err
 		}
+		return handler(ctx, req)
+	}
+}
 
-		decoded, err := base64.StdEncoding.DecodeString(credentials)
+func (a *AuthMD) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context())
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, "can't parse credentials")
+			return err
 		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
 
-		data := strings.Split(string(decoded), ":")
-		if len(data) != 2 {
-			return nil, status.Error(codes.Unauthenticated, "can't parse credentials")
-		}
+func (a *AuthMD) authenticate(ctx context.Context) (context.Context, error) {
+	credentials := a.getAuthCredentials(ctx)
+	if credentials == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
 
-		ctx = context.WithValue(ctx, "user", data[0])
-		return handler(ctx, req)
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "can't parse credentials")
 	}
+
+	data := strings.Split(string(decoded), ":")
+	if len(data) != 2 {
+		return nil, status.Error(codes.Unauthenticated, "can't parse credentials")
+	}
+
+	return context.WithValue(ctx, userKey, data[0]), nil
 }
 
 func (a *AuthMD) getAuthCredentials(ctx context.Context) string {
@@ -61,3 +87,13 @@ func (a *AuthMD) getAuthCredentials(ctx context.Context) string {
 	}
 	return fields[1]
 }
+
+// serverStream overrides the context of the wrapped grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
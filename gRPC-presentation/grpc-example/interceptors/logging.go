@@ -0,0 +1,93 @@
+package interceptors
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/grpc-example/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AccessLog writes a JSON line per handled RPC.
+type AccessLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewAccessLog(w io.Writer) *AccessLog {
+	return &AccessLog{enc: json.NewEncoder(w)}
+}
+
+type accessEntry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Type       string    `json:"type"`
+	Code       string    `json:"code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	Peer       string    `json:"peer,omitempty"`
+	TraceID    string    `json:"trace_id,omitempty"`
+}
+
+func (l *AccessLog) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		start := time.Now()
+		resp, err = handler(ctx, req)
+		l.write(ctx, info.FullMethod, rpcType(false, false), start, err)
+		return resp, err
+	}
+}
+
+func (l *AccessLog) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		l.write(ss.Context(), info.FullMethod, rpcType(info.IsClientStream, info.IsServerStream), start, err)
+		return err
+	}
+}
+
+func (l *AccessLog) write(ctx context.Context, method, typ string, start time.Time, err error) {
+	st := status.Convert(err)
+	entry := accessEntry{
+		Time:       start.UTC(),
+		Method:     method,
+		Type:       typ,
+		Code:       st.Code().String(),
+		DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		entry.Error = st.Message()
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		entry.Peer = p.Addr.String()
+	}
+	if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+		entry.TraceID = sc.TraceID.String()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(entry); err != nil {
+		log.Printf("can't write access log: %v", err)
+	}
+}
+
+func rpcType(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return "bidi_stream"
+	case clientStream:
+		return "client_stream"
+	case serverStream:
+		return "server_stream"
+	default:
+		return "unary"
+	}
+}
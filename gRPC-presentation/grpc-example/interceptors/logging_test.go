@@ -0,0 +1,115 @@
+package interceptors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/grpc-example/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewAccessLog(&buf)
+
+	trace := tracing.SpanContext{TraceID: tracing.TraceID{1}, SpanID: tracing.SpanID{2}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+	ctx = tracing.ContextWithRemoteSpanContext(ctx, trace)
+
+	for _, tt := range []struct {
+		name   string
+		stream *grpc.StreamServerInfo
+		err    error
+		want   accessEntry
+	}{
+		{
+			name: "unary",
+			want: accessEntry{Type: "unary", Code: "OK"},
+		},
+		{
+			name: "unary error",
+			err:  status.Error(codes.NotFound, "no such room"),
+			want: accessEntry{Type: "unary", Code: "NotFound", Error: "no such room"},
+		},
+		{
+			name: "plain error",
+			err:  errors.New("boom"),
+			want: accessEntry{Type: "unary", Code: "Unknown", Error: "boom"},
+		},
+		{
+			name:   "server stream",
+			stream: &grpc.StreamServerInfo{IsServerStream: true},
+			want:   accessEntry{Type: "server_stream", Code: "OK"},
+		},
+		{
+			name:   "client stream",
+			stream: &grpc.StreamServerInfo{IsClientStream: true},
+			err:    status.Error(codes.Canceled, "gone"),
+			want:   accessEntry{Type: "client_stream", Code: "Canceled", Error: "gone"},
+		},
+		{
+			name:   "bidi stream",
+			stream: &grpc.StreamServerInfo{IsClientStream: true, IsServerStream: true},
+			want:   accessEntry{Type: "bidi_stream", Code: "OK"},
+		},
+	} {
+		buf.Reset()
+		method := "/chat.ChatService/" + tt.name
+		if tt.stream == nil {
+			_, err := log.UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(ctx context.Context, req interface{}) (interface{}, error) { return nil, tt.err })
+			if err != tt.err {
+				t.Errorf("%s: expected the handler error %v, got %v", tt.name, tt.err, err)
+			}
+		} else {
+			tt.stream.FullMethod = method
+			err := log.StreamInterceptor()(nil, &serverStream{ctx: ctx}, tt.stream,
+				func(srv interface{}, ss grpc.ServerStream) error { return tt.err })
+			if err != tt.err {
+				t.Errorf("%s: expected the handler error %v, got %v", tt.name, tt.err, err)
+			}
+		}
+
+		var got accessEntry
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Errorf("%s: can't parse %q: %v", tt.name, buf.String(), err)
+			continue
+		}
+		if got.Time.IsZero() || got.DurationMs < 0 {
+			t.Errorf("%s: unexpected time %v and duration %v", tt.name, got.Time, got.DurationMs)
+		}
+		tt.want.Time, tt.want.DurationMs = got.Time, got.DurationMs
+		tt.want.Method = method
+		tt.want.Peer = "10.0.0.1:5000"
+		tt.want.TraceID = trace.TraceID.String()
+		if got != tt.want {
+			t.Errorf("%s:\nexpected %+v\ngot      %+v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAccessLogWithoutPeer(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewAccessLog(&buf).UnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/m"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"peer", "trace_id", "error"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("expected no %s in %s", key, buf.String())
+		}
+	}
+}
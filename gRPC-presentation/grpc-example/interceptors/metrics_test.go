@@ -0,0 +1,94 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+
+	unary := m.UnaryInterceptor()
+	stream := m.StreamInterceptor()
+	for _, tt := range []struct {
+		method string
+		stream *grpc.StreamServerInfo
+		err    error
+	}{
+		{method: "SayHello"},
+		{method: "SayHello"},
+		{method: "SayHello", err: status.Error(codes.InvalidArgument, "bad")},
+		{method: "SayHello", err: status.Error(codes.ResourceExhausted, "slow down")},
+		{method: "History", stream: &grpc.StreamServerInfo{IsServerStream: true}},
+		{method: "History", stream: &grpc.StreamServerInfo{IsServerStream: true}, err: status.Error(codes.Internal, "db")},
+	} {
+		method := "/chat.ChatService/" + tt.method
+		if tt.stream == nil {
+			unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(ctx context.Context, req interface{}) (interface{}, error) { return nil, tt.err })
+		} else {
+			tt.stream.FullMethod = method
+			stream(nil, &serverStream{ctx: context.Background()}, tt.stream,
+				func(srv interface{}, ss grpc.ServerStream) error { return tt.err })
+		}
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, f := range families {
+		for _, metric := range f.Metric {
+			var count uint64
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				count = uint64(metric.Counter.GetValue())
+			case dto.MetricType_HISTOGRAM:
+				count = metric.Histogram.GetSampleCount()
+			}
+			got[f.GetName()] = append(got[f.GetName()], fmt.Sprintf("%s %d", labels(metric), count))
+		}
+		sort.Strings(got[f.GetName()])
+	}
+
+	want := map[string][]string{
+		"grpc_server_handled_total": {
+			"grpc_code=Internal,grpc_method=/chat.ChatService/History,grpc_type=server_stream 1",
+			"grpc_code=InvalidArgument,grpc_method=/chat.ChatService/SayHello,grpc_type=unary 1",
+			"grpc_code=OK,grpc_method=/chat.ChatService/History,grpc_type=server_stream 1",
+			"grpc_code=OK,grpc_method=/chat.ChatService/SayHello,grpc_type=unary 2",
+			"grpc_code=ResourceExhausted,grpc_method=/chat.ChatService/SayHello,grpc_type=unary 1",
+		},
+		"grpc_server_handling_seconds": {
+			"grpc_method=/chat.ChatService/History,grpc_type=server_stream 2",
+			"grpc_method=/chat.ChatService/SayHello,grpc_type=unary 4",
+		},
+	}
+	for name, series := range want {
+		if strings.Join(got[name], "\n") != strings.Join(series, "\n") {
+			t.Errorf("%s:\nexpected %q\ngot      %q", name, series, got[name])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d metrics, got %v", len(want), got)
+	}
+}
+
+func labels(m *dto.Metric) string {
+	pairs := make([]string, len(m.Label))
+	for i, l := range m.Label {
+		pairs[i] = l.GetName() + "=" + l.GetValue()
+	}
+	return strings.Join(pairs, ",")
+}
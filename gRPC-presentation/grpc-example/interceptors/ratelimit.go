@@ -0,0 +1,106 @@
+package interceptors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grpc-example/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader is the trailer with the number of seconds a rejected
+// client should wait before retrying.
+const RetryAfterHeader = "retry-after"
+
+// RateLimitConfig holds the limits per full method name, e.g.
+// "/chat.ChatService/SayHello". Methods not listed get Default.
+type RateLimitConfig struct {
+	Default ratelimit.Limit            `json:"default"`
+	Methods map[string]ratelimit.Limit `json:"methods"`
+}
+
+func LoadRateLimitConfig(path string) (RateLimitConfig, error) {
+	var cfg RateLimitConfig
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c RateLimitConfig) limit(method string) ratelimit.Limit {
+	if l, ok := c.Methods[method]; ok {
+		return l
+	}
+	return c.Default
+}
+
+// RateLimit limits calls per authenticated user and method, so it must be
+// chained after AuthMD.
+type RateLimit struct {
+	Limiter ratelimit.Limiter
+	Config  RateLimitConfig
+}
+
+func (r *RateLimit) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		if err := r.allow(ctx, info.FullMethod, func(md metadata.MD) { grpc.SetTrailer(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (r *RateLimit) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := r.allow(ss.Context(), info.FullMethod, ss.SetTrailer); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (r *RateLimit) allow(ctx context.Context, method string, setTrailer func(metadata.MD)) error {
+	limit := r.Config.limit(method)
+	if limit.Unlimited() {
+		return nil
+	}
+
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+
+	ok, wait, err := r.Limiter.Allow(ctx, user+" "+method, limit)
+	if err != nil {
+		// don't turn limiter outages into service outages
+		log.Printf("rate limiter failed, letting the call through: %v", err)
+		return nil
+	}
+	if ok {
+		return nil
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	setTrailer(metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds)))
+
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded, retry after %ds", seconds))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait.Round(time.Millisecond))}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,74 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/grpc-example/pb"
+	"github.com/grpc-example/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type echoChat struct {
+	pb.UnimplementedChatServiceServer
+}
+
+func (echoChat) SayHello(ctx context.Context, in *pb.Message) (*pb.Message, error) {
+	return in, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	authMD := AuthMD{}
+	rateLimit := RateLimit{
+		Limiter: ratelimit.NewMemory(),
+		Config: RateLimitConfig{
+			Methods: map[string]ratelimit.Limit{
+				"/chat.ChatService/SayHello": {Rate: 0.1, Burst: 2},
+			},
+		},
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(authMD.UnaryInterceptor(), rateLimit.UnaryInterceptor()))
+	pb.RegisterChatServiceServer(srv, echoChat{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewChatServiceClient(conn)
+
+	asUser := func(credentials string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), authHeader, "Bearer "+credentials)
+	}
+	roman := asUser("cm9tYW46cHdk") // roman:pwd
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.SayHello(roman, &pb.Message{}); err != nil {
+			t.Fatalf("call %d within burst failed: %v", i, err)
+		}
+	}
+
+	var trailer metadata.MD
+	_, err = client.SayHello(roman, &pb.Message{}, grpc.Trailer(&trailer))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if values := trailer.Get(RetryAfterHeader); len(values) != 1 || values[0] != "10" {
+		t.Errorf("expected retry-after 10, got %v", values)
+	}
+
+	if _, err := client.SayHello(asUser("a29zeWk6cHdk"), &pb.Message{}); err != nil { // kosyi:pwd
+		t.Errorf("other user was limited: %v", err)
+	}
+}
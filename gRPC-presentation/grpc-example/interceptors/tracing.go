@@ -0,0 +1,85 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/grpc-example/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Tracing starts a span per RPC. The server side continues the trace found
+// in the incoming traceparent metadata, the client side sends it.
+type Tracing struct {
+	Tracer *tracing.Tracer
+}
+
+func (t *Tracing) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx, span := t.startServerSpan(ctx, info.FullMethod)
+		defer func() { finishSpan(span, err) }()
+
+		return handler(ctx, req)
+	}
+}
+
+func (t *Tracing) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, span := t.startServerSpan(ss.Context(), info.FullMethod)
+		defer func() { finishSpan(span, err) }()
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (t *Tracing) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		ctx, span := t.startClientSpan(ctx, method)
+		defer func() { finishSpan(span, err) }()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor only covers the stream creation, the span doesn't
+// wait for the stream to be drained.
+func (t *Tracing) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (cs grpc.ClientStream, err error) {
+		ctx, span := t.startClientSpan(ctx, method)
+		defer func() { finishSpan(span, err) }()
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func (t *Tracing) startServerSpan(ctx context.Context, method string) (context.Context, *tracing.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(tracing.TraceparentHeader); len(values) > 0 {
+			if sc, err := tracing.ParseTraceparent(values[0]); err == nil {
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+			}
+		}
+	}
+
+	ctx, span := t.Tracer.Start(ctx, method, tracing.SpanKindServer)
+	span.SetAttribute("rpc.system", "grpc")
+	if p, ok := peer.FromContext(ctx); ok {
+		span.SetAttribute("net.peer", p.Addr.String())
+	}
+	return ctx, span
+}
+
+func (t *Tracing) startClientSpan(ctx context.Context, method string) (context.Context, *tracing.Span) {
+	ctx, span := t.Tracer.Start(ctx, method, tracing.SpanKindClient)
+	span.SetAttribute("rpc.system", "grpc")
+	ctx = metadata.AppendToOutgoingContext(ctx, tracing.TraceparentHeader, span.Context.Traceparent())
+	return ctx, span
+}
+
+func finishSpan(span *tracing.Span, err error) {
+	st := status.Convert(err)
+	span.SetStatus(st.Code().String(), st.Message())
+	span.Finish()
+}
@@ -0,0 +1,115 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grpc-example/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type spanRecorder struct {
+	spans []*tracing.Span
+}
+
+func (r *spanRecorder) ExportSpan(s *tracing.Span) {
+	r.spans = append(r.spans, s)
+}
+
+func TestTracingServer(t *testing.T) {
+	remote := tracing.SpanContext{TraceID: tracing.TraceID{1}, SpanID: tracing.SpanID{2}}
+
+	for _, tt := range []struct {
+		name      string
+		md        metadata.MD
+		stream    bool
+		err       error
+		continued bool
+		code      string
+	}{
+		{name: "unary", md: metadata.Pairs(tracing.TraceparentHeader, remote.Traceparent()), continued: true, code: "OK"},
+		{name: "stream", md: metadata.Pairs(tracing.TraceparentHeader, remote.Traceparent()), stream: true, continued: true, code: "OK"},
+		{name: "error", md: metadata.Pairs(tracing.TraceparentHeader, remote.Traceparent()), err: status.Error(codes.NotFound, "no room"), continued: true, code: "NotFound"},
+		{name: "no metadata", code: "OK"},
+		{name: "bad traceparent", md: metadata.Pairs(tracing.TraceparentHeader, "garbage"), stream: true, code: "OK"},
+	} {
+		rec := &spanRecorder{}
+		tr := Tracing{Tracer: tracing.NewTracer(rec)}
+		ctx := context.Background()
+		if tt.md != nil {
+			ctx = metadata.NewIncomingContext(ctx, tt.md)
+		}
+
+		// the handler sees the span, so spans it starts are its children
+		var inHandler tracing.SpanContext
+		if tt.stream {
+			tr.StreamInterceptor()(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/m/" + tt.name},
+				func(srv interface{}, ss grpc.ServerStream) error {
+					inHandler = tracing.SpanContextFromContext(ss.Context())
+					return tt.err
+				})
+		} else {
+			tr.UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/m/" + tt.name},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					inHandler = tracing.SpanContextFromContext(ctx)
+					return nil, tt.err
+				})
+		}
+
+		if len(rec.spans) != 1 {
+			t.Errorf("%s: expected one span, got %d", tt.name, len(rec.spans))
+			continue
+		}
+		span := rec.spans[0]
+		if span.Name != "/m/"+tt.name || span.Kind != tracing.SpanKindServer || span.StatusCode != tt.code {
+			t.Errorf("%s: unexpected span %s %s %s", tt.name, span.Name, span.Kind, span.StatusCode)
+		}
+		if inHandler != span.Context {
+			t.Errorf("%s: handler got %v, not the span %v", tt.name, inHandler, span.Context)
+		}
+		if continued := span.Context.TraceID == remote.TraceID && span.Parent == remote.SpanID; continued != tt.continued {
+			t.Errorf("%s: expected trace continued %v, got trace %v parent %v", tt.name, tt.continued, span.Context.TraceID, span.Parent)
+		}
+	}
+}
+
+func TestTracingClient(t *testing.T) {
+	rec := &spanRecorder{}
+	tr := Tracing{Tracer: tracing.NewTracer(rec)}
+	ctx, parent := tr.Tracer.Start(context.Background(), "parent", tracing.SpanKindInternal)
+
+	var sent []string
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sent = md.Get(tracing.TraceparentHeader)
+		return status.Error(codes.Unavailable, "down")
+	}
+	if err := tr.UnaryClientInterceptor()(ctx, "/m/unary", nil, nil, nil, invoker); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the invoker error, got %v", err)
+	}
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, invoker(ctx, method, nil, nil, cc, opts...)
+	}
+	if _, err := tr.StreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/m/stream", streamer); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the streamer error, got %v", err)
+	}
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(rec.spans))
+	}
+	for _, span := range rec.spans {
+		if span.Kind != tracing.SpanKindClient || span.StatusCode != "Unavailable" {
+			t.Errorf("%s: unexpected kind %s and status %s", span.Name, span.Kind, span.StatusCode)
+		}
+		if span.Context.TraceID != parent.Context.TraceID || span.Parent != parent.Context.SpanID {
+			t.Errorf("%s: not a child of the current span", span.Name)
+		}
+	}
+	// the server continues the trace from the last sent traceparent
+	if len(sent) != 1 || sent[0] != rec.spans[1].Context.Traceparent() {
+		t.Errorf("expected traceparent %s, sent %v", rec.spans[1].Context.Traceparent(), sent)
+	}
+}
@@ -0,0 +1,183 @@
+// Package loadgen drives calls at a given concurrency and rate and
+// summarizes their latency and outcome.
+package loadgen
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+type Config struct {
+	// Concurrency is the number of workers making calls, at least 1.
+	Concurrency int
+	// QPS is the target rate of calls across all workers, 0 means as fast as possible.
+	QPS float64
+	// Duration limits the run, 0 means until Requests are made.
+	Duration time.Duration
+	// Requests limits the number of calls, 0 means until Duration passes.
+	Requests int
+}
+
+// Call makes one request, its error is classified by gRPC status code.
+type Call func(ctx context.Context) error
+
+// Latency values are in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type Summary struct {
+	Method      string         `json:"method,omitempty"`
+	Concurrency int            `json:"concurrency"`
+	TargetQPS   float64        `json:"target_qps"`
+	Elapsed     float64        `json:"elapsed_seconds"`
+	Requests    int            `json:"requests"`
+	Errors      int            `json:"errors"`
+	Throughput  float64        `json:"throughput_qps"`
+	Latency     Latency        `json:"latency_ms"`
+	Codes       map[string]int `json:"codes"`
+}
+
+type result struct {
+	latency time.Duration
+	code    string
+	// cut reports whether the run was over when the call returned
+	cut bool
+}
+
+// Run makes calls until ctx is done or the configured limits are reached.
+func Run(ctx context.Context, cfg Config, call Call) Summary {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	permits := schedule(ctx, cfg)
+	results := make(chan result, cfg.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range permits {
+				start := time.Now()
+				err := call(ctx)
+				results <- result{latency: time.Since(start), code: status.Code(err).String(), cut: ctx.Err() != nil}
+			}
+		}()
+	}
+
+	start := time.Now()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	summary := Summary{
+		Concurrency: cfg.Concurrency,
+		TargetQPS:   cfg.QPS,
+		Codes:       make(map[string]int),
+	}
+	var latencies []time.Duration
+	for r := range results {
+		// calls cut by the end of the run aren't the server's fault, the
+		// -d timeout ends them with DeadlineExceeded
+		if (r.code == "Canceled" || r.code == "DeadlineExceeded") && r.cut {
+			continue
+		}
+		latencies = append(latencies, r.latency)
+		summary.Codes[r.code]++
+		if r.code != "OK" {
+			summary.Errors++
+		}
+	}
+
+	summary.Elapsed = time.Since(start).Seconds()
+	summary.Requests = len(latencies)
+	if summary.Elapsed > 0 {
+		summary.Throughput = float64(summary.Requests) / summary.Elapsed
+	}
+	summary.Latency = latencyStats(latencies)
+	return summary
+}
+
+// schedule hands out a permit per call at the target rate.
+func schedule(ctx context.Context, cfg Config) <-chan struct{} {
+	permits := make(chan struct{})
+	go func() {
+		defer close(permits)
+
+		var tick <-chan time.Time
+		if cfg.QPS > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.QPS))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for n := 0; cfg.Requests == 0 || n < cfg.Requests; n++ {
+			if tick != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case permits <- struct{}{}:
+			}
+		}
+	}()
+	return permits
+}
+
+func latencyStats(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	return Latency{
+		Min:  ms(latencies[0]),
+		Mean: ms(total / time.Duration(len(latencies))),
+		P50:  ms(percentile(latencies, 50)),
+		P90:  ms(percentile(latencies, 90)),
+		P95:  ms(percentile(latencies, 95)),
+		P99:  ms(percentile(latencies, 99)),
+		Max:  ms(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile uses the nearest-rank method on sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
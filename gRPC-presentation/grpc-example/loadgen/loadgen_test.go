@@ -0,0 +1,98 @@
+package loadgen
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRunRequests(t *testing.T) {
+	var n int32
+	summary := Run(context.Background(), Config{Concurrency: 4, Requests: 100}, func(ctx context.Context) error {
+		if atomic.AddInt32(&n, 1)%4 == 0 {
+			return status.Error(codes.ResourceExhausted, "slow down")
+		}
+		return nil
+	})
+
+	if summary.Requests != 100 || n != 100 {
+		t.Fatalf("expected 100 requests, got %d (%d calls)", summary.Requests, n)
+	}
+	if summary.Errors != 25 || summary.Codes["ResourceExhausted"] != 25 || summary.Codes["OK"] != 75 {
+		t.Errorf("unexpected outcome: %d errors, codes %v", summary.Errors, summary.Codes)
+	}
+}
+
+func TestRunQPS(t *testing.T) {
+	summary := Run(context.Background(), Config{Concurrency: 2, QPS: 100, Duration: 500 * time.Millisecond}, func(ctx context.Context) error {
+		return nil
+	})
+
+	// the ticker starts a period late and the last call may not fit
+	if summary.Requests < 40 || summary.Requests > 50 {
+		t.Errorf("expected about 50 requests at 100 qps in 0.5s, got %d", summary.Requests)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	var n int32
+	summary := Run(context.Background(), Config{Concurrency: 4, Duration: 100 * time.Millisecond}, func(ctx context.Context) error {
+		if atomic.AddInt32(&n, 1)%2 == 0 {
+			time.Sleep(time.Millisecond)
+			return nil
+		}
+		// in flight when the run ends, as a call with the run's deadline
+		<-ctx.Done()
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	})
+
+	if summary.Errors != 0 || summary.Codes["DeadlineExceeded"] != 0 {
+		t.Errorf("expected no errors, got %d errors, codes %v", summary.Errors, summary.Codes)
+	}
+	if summary.Requests == 0 || summary.Codes["OK"] != summary.Requests {
+		t.Errorf("expected only OK requests, got %d requests, codes %v", summary.Requests, summary.Codes)
+	}
+}
+
+func TestRunCallDeadline(t *testing.T) {
+	// one proc, so the run is over before the failure is summarized
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var n int32
+	summary := Run(ctx, Config{Concurrency: 1}, func(ctx context.Context) error {
+		if atomic.AddInt32(&n, 1) == 1 {
+			// let schedule block sending the next permit, so the worker
+			// takes it and cancels before the collector runs
+			runtime.Gosched()
+			// the call's own deadline, not the run's
+			return status.Error(codes.DeadlineExceeded, "call timeout")
+		}
+		cancel()
+		return status.Error(codes.Canceled, ctx.Err().Error())
+	})
+
+	if summary.Requests != 1 || summary.Errors != 1 || summary.Codes["DeadlineExceeded"] != 1 {
+		t.Errorf("expected the DeadlineExceeded call counted, got %d requests, %d errors, codes %v",
+			summary.Requests, summary.Errors, summary.Codes)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	got := latencyStats(latencies)
+	want := Latency{Min: 1, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,91 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// NewRPCCall builds a Call of method, e.g. "chat.ChatService/SayHello", with
+// the request given as protojson. Streams are read until the server ends them,
+// each call is limited by timeout.
+func NewRPCCall(conn *grpc.ClientConn, method, request string, timeout time.Duration) (Call, error) {
+	md, err := findMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	req := dynamicpb.NewMessage(md.Input())
+	if request != "" {
+		if err := protojson.Unmarshal([]byte(request), req); err != nil {
+			return nil, fmt.Errorf("invalid %s request: %w", md.Input().FullName(), err)
+		}
+	}
+
+	fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+	desc := &grpc.StreamDesc{
+		StreamName:    string(md.Name()),
+		ClientStreams: md.IsStreamingClient(),
+		ServerStreams: md.IsStreamingServer(),
+	}
+	newResponse := func() proto.Message { return dynamicpb.NewMessage(md.Output()) }
+
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if !desc.ClientStreams && !desc.ServerStreams {
+			return conn.Invoke(ctx, fullMethod, req, newResponse())
+		}
+
+		stream, err := conn.NewStream(ctx, desc, fullMethod)
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(req); err != nil && err != io.EOF {
+			return err
+		}
+		if err := stream.CloseSend(); err != nil {
+			return err
+		}
+		for {
+			if err := stream.RecvMsg(newResponse()); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	}, nil
+}
+
+func findMethod(method string) (protoreflect.MethodDescriptor, error) {
+	i := strings.LastIndex(method, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("method %q must be service/method", method)
+	}
+	service, name := strings.TrimPrefix(method[:i], "/"), method[i+1:]
+
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %q: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("service %q has no method %q", service, name)
+	}
+	return md, nil
+}
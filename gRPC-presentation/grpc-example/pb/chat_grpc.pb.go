@@ -19,6 +19,9 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ChatServiceClient interface {
 	SayHello(ctx context.Context, in *Message, opts ...grpc.CallOption) (*Message, error)
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (ChatService_HistoryClient, error)
+	// WatchPresence sends the current presence of users first, then the changes.
+	WatchPresence(ctx context.Context, in *WatchPresenceRequest, opts ...grpc.CallOption) (ChatService_WatchPresenceClient, error)
 }
 
 type chatServiceClient struct {
@@ -38,11 +41,78 @@ func (c *chatServiceClient) SayHello(ctx context.Context, in *Message, opts ...g
 	return out, nil
 }
 
+func (c *chatServiceClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (ChatService_HistoryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ChatService_ServiceDesc.Streams[0], "/chat.ChatService/History", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatServiceHistoryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ChatService_HistoryClient interface {
+	Recv() (*HistoryPage, error)
+	grpc.ClientStream
+}
+
+type chatServiceHistoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatServiceHistoryClient) Recv() (*HistoryPage, error) {
+	m := new(HistoryPage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatServiceClient) WatchPresence(ctx context.Context, in *WatchPresenceRequest, opts ...grpc.CallOption) (ChatService_WatchPresenceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ChatService_ServiceDesc.Streams[1], "/chat.ChatService/WatchPresence", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatServiceWatchPresenceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ChatService_WatchPresenceClient interface {
+	Recv() (*PresenceEvent, error)
+	grpc.ClientStream
+}
+
+type chatServiceWatchPresenceClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatServiceWatchPresenceClient) Recv() (*PresenceEvent, error) {
+	m := new(PresenceEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ChatServiceServer is the server API for ChatService service.
 // All implementations must embed UnimplementedChatServiceServer
 // for forward compatibility
 type ChatServiceServer interface {
 	SayHello(context.Context, *Message) (*Message, error)
+	History(*HistoryRequest, ChatService_HistoryServer) error
+	// WatchPresence sends the current presence of users first, then the changes.
+	WatchPresence(*WatchPresenceRequest, ChatService_WatchPresenceServer) error
 	mustEmbedUnimplementedChatServiceServer()
 }
 
@@ -53,6 +123,12 @@ type UnimplementedChatServiceServer struct {
 func (UnimplementedChatServiceServer) SayHello(context.Context, *Message) (*Message, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SayHello not implemented")
 }
+func (UnimplementedChatServiceServer) History(*HistoryRequest, ChatService_HistoryServer) error {
+	return status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedChatServiceServer) WatchPresence(*WatchPresenceRequest, ChatService_WatchPresenceServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPresence not implemented")
+}
 func (UnimplementedChatServiceServer) mustEmbedUnimplementedChatServiceServer() {}
 
 // UnsafeChatServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +160,48 @@ func _ChatService_SayHello_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChatService_History_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServiceServer).History(m, &chatServiceHistoryServer{stream})
+}
+
+type ChatService_HistoryServer interface {
+	Send(*HistoryPage) error
+	grpc.ServerStream
+}
+
+type chatServiceHistoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatServiceHistoryServer) Send(m *HistoryPage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ChatService_WatchPresence_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPresenceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServiceServer).WatchPresence(m, &chatServiceWatchPresenceServer{stream})
+}
+
+type ChatService_WatchPresenceServer interface {
+	Send(*PresenceEvent) error
+	grpc.ServerStream
+}
+
+type chatServiceWatchPresenceServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatServiceWatchPresenceServer) Send(m *PresenceEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // ChatService_ServiceDesc is the grpc.ServiceDesc for ChatService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -96,6 +214,17 @@ var ChatService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _ChatService_SayHello_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "History",
+			Handler:       _ChatService_History_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchPresence",
+			Handler:       _ChatService_WatchPresence_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "chat.proto",
 }
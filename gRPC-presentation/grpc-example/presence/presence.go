@@ -0,0 +1,183 @@
+// Package presence tracks which users are active, typing or gone per room.
+package presence
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grpc-example/pb"
+)
+
+const (
+	DefaultIdleTimeout   = 5 * time.Minute
+	DefaultTypingTimeout = 10 * time.Second
+
+	// subscriberBuffer is how many events a subscriber may lag behind before
+	// it's dropped.
+	subscriberBuffer = 64
+)
+
+type Event struct {
+	User   string
+	Room   string
+	Status pb.Message_Status
+	At     time.Time
+}
+
+type key struct {
+	user string
+	room string
+}
+
+type entry struct {
+	status pb.Message_Status
+	// seen is the time of the last update from the user
+	seen time.Time
+	// changed is the time status was set
+	changed time.Time
+}
+
+type subscriber struct {
+	room   string
+	events chan Event
+}
+
+// Tracker keeps the presence of users. Users sending nothing for the idle
+// timeout are gone, typing expires back to active after the typing timeout.
+type Tracker struct {
+	idleTimeout   time.Duration
+	typingTimeout time.Duration
+	now           func() time.Time
+
+	mu          sync.Mutex
+	users       map[key]*entry
+	subscribers map[*subscriber]struct{}
+}
+
+func NewTracker(idleTimeout, typingTimeout time.Duration) *Tracker {
+	return &Tracker{
+		idleTimeout:   idleTimeout,
+		typingTimeout: typingTimeout,
+		now:           time.Now,
+		users:         make(map[key]*entry),
+		subscribers:   make(map[*subscriber]struct{}),
+	}
+}
+
+// Update records status sent by user in room. Subscribers are notified only
+// when the status changes.
+func (t *Tracker) Update(user, room string, status pb.Message_Status) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	k := key{user: user, room: room}
+	e, ok := t.users[k]
+
+	if status == pb.Message_Exiting {
+		if ok {
+			delete(t.users, k)
+			t.publish(Event{User: user, Room: room, Status: status, At: now})
+		}
+		return
+	}
+
+	if !ok {
+		e = &entry{}
+		t.users[k] = e
+	}
+	e.seen = now
+	if !ok || e.status != status {
+		e.status = status
+		e.changed = now
+		t.publish(Event{User: user, Room: room, Status: status, At: now})
+	}
+}
+
+// Subscribe returns the current presence in room, or in all rooms if room
+// is empty, and a channel with the following changes. The channel is closed
+// when cancel is called or the subscriber falls behind.
+func (t *Tracker) Subscribe(room string) (snapshot []Event, events <-chan Event, cancel func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k, e := range t.users {
+		if room == "" || k.room == room {
+			snapshot = append(snapshot, Event{User: k.user, Room: k.room, Status: e.status, At: e.changed})
+		}
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Room != snapshot[j].Room {
+			return snapshot[i].Room < snapshot[j].Room
+		}
+		return snapshot[i].User < snapshot[j].User
+	})
+
+	sub := &subscriber{room: room, events: make(chan Event, subscriberBuffer)}
+	t.subscribers[sub] = struct{}{}
+
+	return snapshot, sub.events, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.unsubscribe(sub)
+	}
+}
+
+// Run expires idle and typing users every interval until ctx is done.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Expire()
+		}
+	}
+}
+
+// Expire applies the timeouts at the current time.
+func (t *Tracker) Expire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for k, e := range t.users {
+		switch {
+		case now.Sub(e.seen) >= t.idleTimeout:
+			delete(t.users, k)
+			t.publish(Event{User: k.user, Room: k.room, Status: pb.Message_Exiting, At: now})
+		case e.status == pb.Message_Typing && now.Sub(e.changed) >= t.typingTimeout:
+			e.status = pb.Message_Active
+			e.changed = now
+			t.publish(Event{User: k.user, Room: k.room, Status: pb.Message_Active, At: now})
+		}
+	}
+}
+
+// publish must be called with mu held.
+func (t *Tracker) publish(ev Event) {
+	for sub := range t.subscribers {
+		if sub.room != "" && sub.room != ev.Room {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			t.unsubscribe(sub)
+		}
+	}
+}
+
+// unsubscribe must be called with mu held.
+func (t *Tracker) unsubscribe(sub *subscriber) {
+	if _, ok := t.subscribers[sub]; !ok {
+		return
+	}
+	delete(t.subscribers, sub)
+	close(sub.events)
+}
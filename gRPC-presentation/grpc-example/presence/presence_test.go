@@ -0,0 +1,87 @@
+package presence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grpc-example/pb"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker(time.Minute, 5*time.Second)
+	tr.now = func() time.Time { return now }
+
+	tr.Update("roman", "go", pb.Message_Active)
+	tr.Update("kosyi", "rust", pb.Message_Active)
+
+	snapshot, events, cancel := tr.Subscribe("go")
+	defer cancel()
+	if len(snapshot) != 1 || snapshot[0].User != "roman" || snapshot[0].Status != pb.Message_Active {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+
+	expect := func(user string, status pb.Message_Status) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.User != user || ev.Status != status {
+				t.Errorf("expected %s %v, got %+v", user, status, ev)
+			}
+		default:
+			t.Errorf("expected %s %v, got nothing", user, status)
+		}
+	}
+	expectNothing := func() {
+		t.Helper()
+		select {
+		case ev := <-events:
+			t.Errorf("unexpected event %+v", ev)
+		default:
+		}
+	}
+
+	tr.Update("roman", "go", pb.Message_Active)
+	tr.Update("kosyi", "rust", pb.Message_Typing)
+	expectNothing()
+
+	tr.Update("roman", "go", pb.Message_Typing)
+	expect("roman", pb.Message_Typing)
+
+	now = now.Add(5 * time.Second)
+	tr.Expire()
+	expect("roman", pb.Message_Active)
+
+	now = now.Add(time.Minute)
+	tr.Expire()
+	expect("roman", pb.Message_Exiting)
+
+	tr.Update("roman", "go", pb.Message_Active)
+	expect("roman", pb.Message_Active)
+	tr.Update("roman", "go", pb.Message_Exiting)
+	expect("roman", pb.Message_Exiting)
+	tr.Update("roman", "go", pb.Message_Exiting)
+	expectNothing()
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	tr := NewTracker(time.Minute, time.Second)
+	_, events, cancel := tr.Subscribe("")
+	defer cancel()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		status := pb.Message_Active
+		if i%2 == 1 {
+			status = pb.Message_Typing
+		}
+		tr.Update("roman", "go", status)
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("expected %d buffered events before the channel is closed, got %d", subscriberBuffer, n)
+	}
+}
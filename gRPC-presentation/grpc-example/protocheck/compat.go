@@ -0,0 +1,333 @@
+package protocheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type Level int
+
+const (
+	// Wire changes break decoding of data produced with the baseline schema
+	// or break calls of existing clients.
+	Wire Level = iota
+	// JSON changes only break the protojson mapping, used by the gateway.
+	JSON
+)
+
+func (l Level) String() string {
+	if l == JSON {
+		return "json"
+	}
+	return "wire"
+}
+
+type Change struct {
+	Level Level
+	// Element is the full name of the changed element.
+	Element string
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Level, c.Element, c.Message)
+}
+
+// Compare reports incompatible changes from baseline to current. Additions
+// are compatible and not reported.
+func Compare(baseline, current *descriptorpb.FileDescriptorSet) []Change {
+	c := &comparer{}
+	prev, cur := index(baseline), index(current)
+
+	for name, m := range prev.messages {
+		if n, ok := cur.messages[name]; ok {
+			c.compareMessage(name, m, n)
+		} else {
+			c.add(Wire, name, "message removed")
+		}
+	}
+	for name, e := range prev.enums {
+		if n, ok := cur.enums[name]; ok {
+			c.compareEnum(name, e, n)
+		} else {
+			c.add(Wire, name, "enum removed")
+		}
+	}
+	for name, s := range prev.services {
+		if n, ok := cur.services[name]; ok {
+			c.compareService(name, s, n)
+		} else {
+			c.add(Wire, name, "service removed")
+		}
+	}
+
+	sort.Slice(c.changes, func(i, j int) bool {
+		if c.changes[i].Element != c.changes[j].Element {
+			return c.changes[i].Element < c.changes[j].Element
+		}
+		return c.changes[i].Message < c.changes[j].Message
+	})
+	return c.changes
+}
+
+type comparer struct {
+	changes []Change
+}
+
+func (c *comparer) add(level Level, element, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Level: level, Element: element, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *comparer) compareMessage(name string, prev, cur *descriptorpb.DescriptorProto) {
+	byNumber := make(map[int32]*descriptorpb.FieldDescriptorProto)
+	for _, f := range cur.Field {
+		byNumber[f.GetNumber()] = f
+	}
+
+	for _, pf := range prev.Field {
+		element := name + "." + pf.GetName()
+		cf, ok := byNumber[pf.GetNumber()]
+		if !ok {
+			if moved := fieldByName(cur, pf.GetName()); moved != nil {
+				c.add(Wire, element, "field number changed from %d to %d", pf.GetNumber(), moved.GetNumber())
+			} else if !numberReserved(cur, pf.GetNumber()) {
+				c.add(Wire, element, "field %d removed without reserving its number", pf.GetNumber())
+			}
+			continue
+		}
+
+		if pf.GetName() != cf.GetName() {
+			c.add(JSON, element, "field %d renamed to %s", pf.GetNumber(), cf.GetName())
+		} else if pf.GetJsonName() != cf.GetJsonName() {
+			c.add(JSON, element, "json name changed from %s to %s", pf.GetJsonName(), cf.GetJsonName())
+		}
+		if pf.GetLabel() != cf.GetLabel() && (isRepeated(pf) || isRepeated(cf)) {
+			c.add(Wire, element, "cardinality changed from %s to %s", label(pf), label(cf))
+		}
+		if !compatibleTypes(pf, cf) {
+			c.add(Wire, element, "type changed from %s to %s", typeName(pf), typeName(cf))
+		}
+		if pf.OneofIndex != nil && cf.OneofIndex != nil {
+			po, co := prev.OneofDecl[pf.GetOneofIndex()].GetName(), cur.OneofDecl[cf.GetOneofIndex()].GetName()
+			if po != co {
+				c.add(Wire, element, "moved from oneof %s to %s", po, co)
+			}
+		} else if (pf.OneofIndex == nil) != (cf.OneofIndex == nil) && !cf.GetProto3Optional() && !pf.GetProto3Optional() {
+			c.add(Wire, element, "moved in or out of a oneof")
+		}
+	}
+}
+
+func (c *comparer) compareEnum(name string, prev, cur *descriptorpb.EnumDescriptorProto) {
+	byNumber := make(map[int32]*descriptorpb.EnumValueDescriptorProto)
+	for _, v := range cur.Value {
+		byNumber[v.GetNumber()] = v
+	}
+
+	for _, pv := range prev.Value {
+		element := name + "." + pv.GetName()
+		cv, ok := byNumber[pv.GetNumber()]
+		if !ok {
+			if !enumNumberReserved(cur, pv.GetNumber()) {
+				c.add(Wire, element, "value %d removed without reserving its number", pv.GetNumber())
+			}
+			continue
+		}
+		if pv.GetName() != cv.GetName() {
+			c.add(JSON, element, "value %d renamed to %s", pv.GetNumber(), cv.GetName())
+		}
+	}
+}
+
+func (c *comparer) compareService(name string, prev, cur *descriptorpb.ServiceDescriptorProto) {
+	methods := make(map[string]*descriptorpb.MethodDescriptorProto)
+	for _, m := range cur.Method {
+		methods[m.GetName()] = m
+	}
+
+	for _, pm := range prev.Method {
+		element := name + "." + pm.GetName()
+		cm, ok := methods[pm.GetName()]
+		if !ok {
+			c.add(Wire, element, "method removed")
+			continue
+		}
+		if pm.GetInputType() != cm.GetInputType() {
+			c.add(Wire, element, "request type changed from %s to %s", trimDot(pm.GetInputType()), trimDot(cm.GetInputType()))
+		}
+		if pm.GetOutputType() != cm.GetOutputType() {
+			c.add(Wire, element, "response type changed from %s to %s", trimDot(pm.GetOutputType()), trimDot(cm.GetOutputType()))
+		}
+		if pm.GetClientStreaming() != cm.GetClientStreaming() || pm.GetServerStreaming() != cm.GetServerStreaming() {
+			c.add(Wire, element, "streaming changed from %s to %s", streaming(pm), streaming(cm))
+		}
+	}
+}
+
+type wireClass int
+
+const (
+	varintClass wireClass = iota
+	zigzag32Class
+	zigzag64Class
+	fixed32Class
+	fixed64Class
+	lengthDelimitedClass
+	groupClass
+)
+
+// classes groups types that can be read from each other's encoding,
+// see https://developers.google.com/protocol-buffers/docs/proto3#updating.
+var classes = map[descriptorpb.FieldDescriptorProto_Type]wireClass{
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:    varintClass,
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:    varintClass,
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   varintClass,
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   varintClass,
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL:     varintClass,
+	descriptorpb.FieldDescriptorProto_TYPE_ENUM:     varintClass,
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32:   zigzag32Class,
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64:   zigzag64Class,
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  fixed32Class,
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: fixed32Class,
+	descriptorpb.FieldDescriptorProto_TYPE_FLOAT:    fixed32Class,
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  fixed64Class,
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: fixed64Class,
+	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:   fixed64Class,
+	descriptorpb.FieldDescriptorProto_TYPE_STRING:   lengthDelimitedClass,
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES:    lengthDelimitedClass,
+	descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:  lengthDelimitedClass,
+	descriptorpb.FieldDescriptorProto_TYPE_GROUP:    groupClass,
+}
+
+func compatibleTypes(prev, cur *descriptorpb.FieldDescriptorProto) bool {
+	pt, ct := prev.GetType(), cur.GetType()
+	if pt == ct {
+		// messages and enums must keep their type, other ones with the same
+		// layout could decode garbage silently
+		return prev.GetTypeName() == cur.GetTypeName()
+	}
+	// floats share the encoding with fixed ints but not the meaning
+	for _, t := range []descriptorpb.FieldDescriptorProto_Type{pt, ct} {
+		if t == descriptorpb.FieldDescriptorProto_TYPE_FLOAT || t == descriptorpb.FieldDescriptorProto_TYPE_DOUBLE ||
+			t == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE || t == descriptorpb.FieldDescriptorProto_TYPE_ENUM {
+			return false
+		}
+	}
+	return classes[pt] == classes[ct]
+}
+
+type descriptors struct {
+	messages map[string]*descriptorpb.DescriptorProto
+	enums    map[string]*descriptorpb.EnumDescriptorProto
+	services map[string]*descriptorpb.ServiceDescriptorProto
+}
+
+func index(set *descriptorpb.FileDescriptorSet) descriptors {
+	d := descriptors{
+		messages: make(map[string]*descriptorpb.DescriptorProto),
+		enums:    make(map[string]*descriptorpb.EnumDescriptorProto),
+		services: make(map[string]*descriptorpb.ServiceDescriptorProto),
+	}
+	for _, f := range set.File {
+		prefix := f.GetPackage()
+		d.addMessages(prefix, f.MessageType)
+		d.addEnums(prefix, f.EnumType)
+		for _, s := range f.Service {
+			d.services[join(prefix, s.GetName())] = s
+		}
+	}
+	return d
+}
+
+func (d descriptors) addMessages(prefix string, messages []*descriptorpb.DescriptorProto) {
+	for _, m := range messages {
+		name := join(prefix, m.GetName())
+		if m.GetOptions().GetMapEntry() {
+			// map entries are compared through the map field type
+			continue
+		}
+		d.messages[name] = m
+		d.addMessages(name, m.NestedType)
+		d.addEnums(name, m.EnumType)
+	}
+}
+
+func (d descriptors) addEnums(prefix string, enums []*descriptorpb.EnumDescriptorProto) {
+	for _, e := range enums {
+		d.enums[join(prefix, e.GetName())] = e
+	}
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func fieldByName(m *descriptorpb.DescriptorProto, name string) *descriptorpb.FieldDescriptorProto {
+	for _, f := range m.Field {
+		if f.GetName() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func numberReserved(m *descriptorpb.DescriptorProto, number int32) bool {
+	for _, r := range m.ReservedRange {
+		// message ranges are end exclusive
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func enumNumberReserved(e *descriptorpb.EnumDescriptorProto, number int32) bool {
+	for _, r := range e.ReservedRange {
+		// enum ranges are end inclusive
+		if number >= r.GetStart() && number <= r.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func isRepeated(f *descriptorpb.FieldDescriptorProto) bool {
+	return f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+}
+
+func label(f *descriptorpb.FieldDescriptorProto) string {
+	if isRepeated(f) {
+		return "repeated"
+	}
+	return "singular"
+}
+
+func typeName(f *descriptorpb.FieldDescriptorProto) string {
+	if f.GetTypeName() != "" {
+		return trimDot(f.GetTypeName())
+	}
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+func streaming(m *descriptorpb.MethodDescriptorProto) string {
+	switch {
+	case m.GetClientStreaming() && m.GetServerStreaming():
+		return "bidi streaming"
+	case m.GetClientStreaming():
+		return "client streaming"
+	case m.GetServerStreaming():
+		return "server streaming"
+	default:
+		return "unary"
+	}
+}
+
+func trimDot(name string) string {
+	return strings.TrimPrefix(name, ".")
+}
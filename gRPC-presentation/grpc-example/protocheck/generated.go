@@ -0,0 +1,36 @@
+package protocheck
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// CheckGenerated compares the descriptors compiled into the generated Go code,
+// which registers itself in files, with the descriptors parsed from sources.
+// It returns the names of files whose generated code is missing or outdated.
+func CheckGenerated(sources *descriptorpb.FileDescriptorSet, files *protoregistry.Files) []string {
+	var stale []string
+	for _, src := range sources.File {
+		fd, err := files.FindFileByPath(src.GetName())
+		if err != nil {
+			stale = append(stale, fmt.Sprintf("%s: no generated code", src.GetName()))
+			continue
+		}
+
+		generated := protodesc.ToFileDescriptorProto(fd)
+		if !proto.Equal(withoutSourceInfo(src), withoutSourceInfo(generated)) {
+			stale = append(stale, fmt.Sprintf("%s: generated code is out of date", src.GetName()))
+		}
+	}
+	return stale
+}
+
+func withoutSourceInfo(fd *descriptorpb.FileDescriptorProto) *descriptorpb.FileDescriptorProto {
+	fd = proto.Clone(fd).(*descriptorpb.FileDescriptorProto)
+	fd.SourceCodeInfo = nil
+	return fd
+}
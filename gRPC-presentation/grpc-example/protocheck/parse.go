@@ -0,0 +1,64 @@
+// Package protocheck guards the protos of this module: it detects changes
+// that break compatibility with a baseline descriptor set and generated Go
+// code which doesn't match the .proto sources.
+package protocheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ParseDir parses every .proto file of dir, imports are resolved relative to dir.
+func ParseDir(dir string) (*descriptorpb.FileDescriptorSet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.proto"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .proto files in %s", dir)
+	}
+
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+
+	parser := protoparse.Parser{ImportPaths: []string{dir}}
+	files, err := parser.ParseFiles(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range files {
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+	return set, nil
+}
+
+func ReadDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return set, nil
+}
+
+func WriteDescriptorSet(path string, set *descriptorpb.FileDescriptorSet) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
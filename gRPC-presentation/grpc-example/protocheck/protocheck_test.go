@@ -0,0 +1,161 @@
+package protocheck
+
+import (
+	"strings"
+	"testing"
+
+	_ "github.com/grpc-example/pb"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestRepositoryProtos keeps the committed generated code and baseline in sync with protos/.
+func TestRepositoryProtos(t *testing.T) {
+	current, err := ParseDir("../protos")
+	if err != nil {
+		t.Fatalf("ParseDir: %v", err)
+	}
+	for _, problem := range CheckGenerated(current, protoregistry.GlobalFiles) {
+		t.Errorf("%s, regenerate pb from protos/", problem)
+	}
+
+	baseline, err := ReadDescriptorSet("../protos/baseline.binpb")
+	if err != nil {
+		t.Fatalf("ReadDescriptorSet: %v", err)
+	}
+	for _, change := range Compare(baseline, current) {
+		if change.Level == Wire {
+			t.Errorf("incompatible change %s", change)
+		}
+	}
+}
+
+const baselineProto = `
+syntax = "proto3";
+package chat;
+
+message Person {
+  string name = 1;
+}
+
+message Message {
+  uint32 id = 1;
+  enum Status {
+    Active = 0;
+    Typing = 1;
+    Exiting = 2;
+  }
+  string body = 2;
+  int32 number = 3;
+  repeated string phone_numbers = 4;
+  Person person_info = 5;
+  int64 created = 6;
+  string removed = 7;
+}
+
+service ChatService {
+  rpc SayHello (Message) returns (Message);
+  rpc Watch (Message) returns (stream Message);
+}
+`
+
+func parse(t *testing.T, source string) *descriptorpb.FileDescriptorSet {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"chat.proto": source})}
+	files, err := parser.ParseFiles("chat.proto")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{files[0].AsFileDescriptorProto()}}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace []string
+		changes []string
+	}{
+		{
+			name:    "no changes",
+			changes: nil,
+		},
+		{
+			name:    "added field",
+			replace: []string{"int64 created = 6;", "int64 created = 6;\n  string added = 10;"},
+			changes: nil,
+		},
+		{
+			name:    "renumbered field",
+			replace: []string{"string body = 2;", "string body = 8;"},
+			changes: []string{"wire: chat.Message.body: field number changed from 2 to 8"},
+		},
+		{
+			name:    "message type changed",
+			replace: []string{"Person person_info = 5;", "Message person_info = 5;"},
+			changes: []string{"wire: chat.Message.person_info: type changed from chat.Person to chat.Message"},
+		},
+		{
+			name:    "compatible scalar type",
+			replace: []string{"int32 number = 3;", "int64 number = 3;"},
+			changes: nil,
+		},
+		{
+			name:    "incompatible scalar type",
+			replace: []string{"int64 created = 6;", "string created = 6;"},
+			changes: []string{"wire: chat.Message.created: type changed from int64 to string"},
+		},
+		{
+			name:    "cardinality",
+			replace: []string{"repeated string phone_numbers = 4;", "string phone_numbers = 4;"},
+			changes: []string{"wire: chat.Message.phone_numbers: cardinality changed from repeated to singular"},
+		},
+		{
+			name:    "removed field",
+			replace: []string{"string removed = 7;", ""},
+			changes: []string{"wire: chat.Message.removed: field 7 removed without reserving its number"},
+		},
+		{
+			name:    "removed reserved field",
+			replace: []string{"string removed = 7;", "reserved 7;"},
+			changes: nil,
+		},
+		{
+			name:    "renamed field",
+			replace: []string{"string body = 2;", "string text = 2;"},
+			changes: []string{"json: chat.Message.body: field 2 renamed to text"},
+		},
+		{
+			name:    "enum value renumbered",
+			replace: []string{"Exiting = 2;", "Exiting = 3;"},
+			changes: []string{"wire: chat.Message.Status.Exiting: value 2 removed without reserving its number"},
+		},
+		{
+			name:    "streaming changed",
+			replace: []string{"returns (stream Message)", "returns (Message)"},
+			changes: []string{"wire: chat.ChatService.Watch: streaming changed from server streaming to unary"},
+		},
+		{
+			name:    "method removed",
+			replace: []string{"rpc Watch (Message) returns (stream Message);", ""},
+			changes: []string{"wire: chat.ChatService.Watch: method removed"},
+		},
+	}
+
+	baseline := parse(t, baselineProto)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := baselineProto
+			if tt.replace != nil {
+				source = strings.Replace(source, tt.replace[0], tt.replace[1], 1)
+			}
+
+			var got []string
+			for _, c := range Compare(baseline, parse(t, source)) {
+				got = append(got, c.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.changes, "\n") {
+				t.Errorf("expected changes\n%s\ngot\n%s", strings.Join(tt.changes, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
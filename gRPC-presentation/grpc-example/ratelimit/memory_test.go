@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryAllow(t *testing.T) {
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if ok, _, _ := m.Allow(ctx, "roman", limit); !ok {
+			t.Fatalf("call %d within burst was rejected", i)
+		}
+	}
+
+	ok, wait, _ := m.Allow(ctx, "roman", limit)
+	if ok {
+		t.Fatal("call over burst was allowed")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms, got %v", wait)
+	}
+
+	if ok, _, _ := m.Allow(ctx, "kosyi", limit); !ok {
+		t.Error("buckets of different keys aren't independent")
+	}
+
+	now = now.Add(wait)
+	if ok, _, _ := m.Allow(ctx, "roman", limit); !ok {
+		t.Error("call after refill was rejected")
+	}
+
+	now = now.Add(time.Hour)
+	m.Allow(ctx, "kosyi", limit)
+	if _, ok := m.buckets["roman"]; ok {
+		t.Error("refilled bucket wasn't swept")
+	}
+}
+
+func TestMemoryUnlimited(t *testing.T) {
+	m := NewMemory()
+	for i := 0; i < 100; i++ {
+		if ok, _, _ := m.Allow(context.Background(), "roman", Limit{}); !ok {
+			t.Fatal("zero limit must not reject calls")
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Package ratelimit implements token bucket rate limiting.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit allows Rate events per second on average with bursts of up to Burst events.
+// A zero Rate means no limit.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Limiter keeps a token bucket per key. It's an interface so buckets can be
+// moved to a store shared between server instances.
+type Limiter interface {
+	// Allow takes a token from the bucket of key. When the bucket is empty it
+	// returns false and how long to wait until the next token is available.
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grpc-example/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Memory struct {
+	mu       sync.RWMutex
+	lastID   uint32
+	messages []*pb.Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Save(ctx context.Context, msg *pb.Message) (*pb.Message, error) {
+	stored := proto.Clone(msg).(*pb.Message)
+
+	m.mu.Lock()
+	m.lastID++
+	stored.Id = m.lastID
+	stored.LastUpdated = timestamppb.New(time.Now().UTC())
+	m.messages = append(m.messages, stored)
+	m.mu.Unlock()
+
+	return proto.Clone(stored).(*pb.Message), nil
+}
+
+func (m *Memory) List(ctx context.Context, q Query) ([]*pb.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := make([]*pb.Message, 0)
+	for _, msg := range m.messages {
+		if !q.match(msg.Room, msg.Id, msg.LastUpdated.AsTime()) {
+			continue
+		}
+		res = append(res, proto.Clone(msg).(*pb.Message))
+		if q.Limit > 0 && len(res) == q.Limit {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grpc-example/pb"
+	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const createMessages = `
+CREATE TABLE IF NOT EXISTS messages (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	room       TEXT    NOT NULL,
+	created_at INTEGER NOT NULL,
+	payload    BLOB    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS messages_room_created_at ON messages (room, created_at);`
+
+// SQLite keeps messages in a sqlite database. The message itself is stored
+// as a marshaled protobuf, so new Message fields don't require a migration.
+type SQLite struct {
+	db *sql.DB
+}
+
+func NewSQLite(dsn string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// sqlite allows a single writer, in-memory databases also live per connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(createMessages); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Save(ctx context.Context, msg *pb.Message) (*pb.Message, error) {
+	stored := proto.Clone(msg).(*pb.Message)
+	stored.Id = 0
+	stored.LastUpdated = timestamppb.New(time.Now().UTC())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO messages (room, created_at, payload) VALUES (?, ?, ?)",
+		stored.Room, stored.LastUpdated.AsTime().UnixNano(), []byte{})
+	if err != nil {
+		return nil, fmt.Errorf("insert message: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if id > math.MaxUint32 {
+		return nil, fmt.Errorf("message id %d overflows uint32", id)
+	}
+	stored.Id = uint32(id)
+
+	payload, err := proto.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE messages SET payload = ? WHERE id = ?", payload, id); err != nil {
+		return nil, fmt.Errorf("update message: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func (s *SQLite) List(ctx context.Context, q Query) ([]*pb.Message, error) {
+	query := "SELECT payload FROM messages WHERE room = ? AND id > ?"
+	args := []interface{}{q.Room, q.AfterID}
+	if !q.From.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		query += " AND created_at < ?"
+		args = append(args, q.To.UnixNano())
+	}
+	query += " ORDER BY id"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select messages: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]*pb.Message, 0)
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(payload, msg); err != nil {
+			return nil, fmt.Errorf("unmarshal message: %w", err)
+		}
+		res = append(res, msg)
+	}
+	return res, rows.Err()
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
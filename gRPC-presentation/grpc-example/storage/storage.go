@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/grpc-example/pb"
+)
+
+// Query selects stored messages of a single room.
+type Query struct {
+	Room string
+	// From is inclusive, a zero value means from the beginning.
+	From time.Time
+	// To is exclusive, a zero value means no upper bound.
+	To time.Time
+	// AfterID skips messages with id less or equal to it, used as a pagination cursor.
+	AfterID uint32
+	// Limit caps the number of returned messages, 0 means no limit.
+	Limit int
+}
+
+// Storage keeps chat messages. Implementations must be safe for concurrent use.
+type Storage interface {
+	// Save stores a copy of msg, assigning its id and last_updated time,
+	// and returns the stored copy.
+	Save(ctx context.Context, msg *pb.Message) (*pb.Message, error)
+	// List returns messages matching q ordered by id.
+	List(ctx context.Context, q Query) ([]*pb.Message, error)
+	Close() error
+}
+
+func (q Query) match(room string, id uint32, ts time.Time) bool {
+	if room != q.Room || id <= q.AfterID {
+		return false
+	}
+	if !q.From.IsZero() && ts.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !ts.Before(q.To) {
+		return false
+	}
+	return true
+}
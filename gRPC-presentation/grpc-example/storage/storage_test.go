@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grpc-example/pb"
+)
+
+func TestStorages(t *testing.T) {
+	sqlite, err := NewSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, st := range map[string]Storage{"memory": NewMemory(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			testStorage(t, st)
+		})
+	}
+}
+
+func testStorage(t *testing.T, st Storage) {
+	ctx := context.Background()
+	start := time.Now().UTC()
+
+	for i, room := range []string{"go", "rust", "go", "go"} {
+		stored, err := st.Save(ctx, &pb.Message{Id: 100, Room: room, Number: int32(i)})
+		if err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if stored.Id != uint32(i+1) {
+			t.Errorf("expected server assigned id %d, got %d", i+1, stored.Id)
+		}
+		if stored.LastUpdated.AsTime().Before(start) {
+			t.Errorf("last_updated %v is before %v", stored.LastUpdated.AsTime(), start)
+		}
+	}
+
+	messages, err := st.List(ctx, Query{Room: "go"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if ids := messageIDs(messages); !equalIDs(ids, []uint32{1, 3, 4}) {
+		t.Errorf("expected ids [1 3 4], got %v", ids)
+	}
+
+	messages, err = st.List(ctx, Query{Room: "go", AfterID: 1, Limit: 1})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if ids := messageIDs(messages); !equalIDs(ids, []uint32{3}) {
+		t.Errorf("expected ids [3], got %v", ids)
+	}
+
+	messages, err = st.List(ctx, Query{Room: "go", To: start})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected no messages before %v, got %v", start, messageIDs(messages))
+	}
+}
+
+func messageIDs(messages []*pb.Message) []uint32 {
+	ids := make([]uint32, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.Id)
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Exporter receives finished spans. Implementations must be safe for
+// concurrent use and should not block for long, it's called inline.
+type Exporter interface {
+	ExportSpan(s *Span)
+}
+
+// WriterExporter writes every span as a JSON line.
+type WriterExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{enc: json.NewEncoder(w)}
+}
+
+// NewStdoutExporter is meant for local testing.
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+type jsonSpan struct {
+	TraceID       string            `json:"trace_id"`
+	SpanID        string            `json:"span_id"`
+	ParentSpanID  string            `json:"parent_span_id,omitempty"`
+	Name          string            `json:"name"`
+	Kind          SpanKind          `json:"kind"`
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	DurationMs    float64           `json:"duration_ms"`
+	StatusCode    string            `json:"status_code,omitempty"`
+	StatusMessage string            `json:"status_message,omitempty"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+}
+
+func (e *WriterExporter) ExportSpan(s *Span) {
+	s.mu.Lock()
+	js := jsonSpan{
+		TraceID:       s.Context.TraceID.String(),
+		SpanID:        s.Context.SpanID.String(),
+		Name:          s.Name,
+		Kind:          s.Kind,
+		Start:         s.Start,
+		End:           s.End,
+		DurationMs:    float64(s.End.Sub(s.Start)) / float64(time.Millisecond),
+		StatusCode:    s.StatusCode,
+		StatusMessage: s.StatusMessage,
+		Attributes:    make(map[string]string, len(s.Attributes)),
+	}
+	for k, v := range s.Attributes {
+		js.Attributes[k] = v
+	}
+	if s.Parent.IsValid() {
+		js.ParentSpanID = s.Parent.String()
+	}
+	s.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.enc.Encode(js); err != nil {
+		log.Printf("can't export span: %v", err)
+	}
+}
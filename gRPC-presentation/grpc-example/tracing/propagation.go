@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceparentHeader carries the span context in W3C Trace Context format:
+// version-trace_id-parent_id-flags.
+const TraceparentHeader = "traceparent"
+
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return sc, fmt.Errorf("unsupported traceparent %q", value)
+	}
+	if err := decodeID(sc.TraceID[:], parts[1]); err != nil {
+		return sc, fmt.Errorf("invalid trace id: %w", err)
+	}
+	if err := decodeID(sc.SpanID[:], parts[2]); err != nil {
+		return sc, fmt.Errorf("invalid parent id: %w", err)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("zero ids in traceparent %q", value)
+	}
+	return sc, nil
+}
+
+func decodeID(dst []byte, s string) error {
+	if hex.DecodedLen(len(s)) != len(dst) {
+		return fmt.Errorf("expected %d hex chars, got %q", 2*len(dst), s)
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
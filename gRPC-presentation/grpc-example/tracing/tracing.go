@@ -0,0 +1,158 @@
+// Package tracing is a small OpenTelemetry-like tracer: spans are grouped in
+// traces, carried in a context and handed to an Exporter when they end.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext identifies a span across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type SpanKind string
+
+const (
+	SpanKindInternal SpanKind = "internal"
+	SpanKindServer   SpanKind = "server"
+	SpanKindClient   SpanKind = "client"
+)
+
+type Span struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	// StatusCode is a gRPC code name, "OK" for successful spans.
+	StatusCode    string
+	StatusMessage string
+
+	mu     sync.Mutex
+	ended  bool
+	tracer *Tracer
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+func (s *Span) SetStatus(code, message string) {
+	s.mu.Lock()
+	s.StatusCode = code
+	s.StatusMessage = message
+	s.mu.Unlock()
+}
+
+// Finish ends the span and exports it, subsequent calls do nothing.
+func (s *Span) Finish() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now().UTC()
+	s.mu.Unlock()
+
+	s.tracer.export(s)
+}
+
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a tracer which hands finished spans to exporter,
+// spans are dropped if exporter is nil.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start creates a span which is a child of the span found in ctx, either
+// local or remote one, or a root span of a new trace otherwise.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now().UTC(),
+		Attributes: make(map[string]string),
+		tracer:     t,
+	}
+
+	if parent := SpanContextFromContext(ctx); parent.IsValid() {
+		span.Context.TraceID = parent.TraceID
+		span.Parent = parent.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+	}
+	rand.Read(span.Context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *Tracer) export(s *Span) {
+	if t.exporter == nil {
+		return
+	}
+	t.exporter.ExportSpan(s)
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// SpanFromContext returns the current local span or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext marks sc, received from another process,
+// as the parent of spans started from the returned context.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the context of the current local span or
+// the remote parent, whatever is found first.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.Context
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
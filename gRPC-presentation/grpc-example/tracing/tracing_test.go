@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+type recorder struct {
+	spans []*Span
+}
+
+func (r *recorder) ExportSpan(s *Span) {
+	r.spans = append(r.spans, s)
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	_, span := NewTracer(nil).Start(context.Background(), "test", SpanKindInternal)
+
+	sc, err := ParseTraceparent(span.Context.Traceparent())
+	if err != nil {
+		t.Fatalf("ParseTraceparent: %v", err)
+	}
+	if sc != span.Context {
+		t.Errorf("expected %v, got %v", span.Context, sc)
+	}
+
+	for _, v := range []string{
+		"",
+		"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b71692033-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319x-b7ad6b7169203331-01",
+	} {
+		if _, err := ParseTraceparent(v); err == nil {
+			t.Errorf("expected error for %q", v)
+		}
+	}
+}
+
+func TestChildSpans(t *testing.T) {
+	rec := &recorder{}
+	tracer := NewTracer(rec)
+
+	remote := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}}
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, parent := tracer.Start(ctx, "parent", SpanKindServer)
+	_, child := tracer.Start(ctx, "child", SpanKindInternal)
+	child.Finish()
+	child.Finish()
+	parent.Finish()
+
+	if len(rec.spans) != 2 {
+		t.Fatalf("expected 2 exported spans, got %d", len(rec.spans))
+	}
+	if parent.Context.TraceID != remote.TraceID || parent.Parent != remote.SpanID {
+		t.Errorf("parent span doesn't continue the remote trace: %+v", parent.Context)
+	}
+	if child.Context.TraceID != remote.TraceID || child.Parent != parent.Context.SpanID {
+		t.Errorf("child span isn't linked to its parent: %+v", child.Context)
+	}
+}
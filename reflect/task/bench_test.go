@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+var (
+	benchCity = City{"sf", 5000000, 567896, "mr jones"}
+	// benchLarge is a nested payload of about 100KB
+	benchLarge = func() []Citizen {
+		r := rand.New(rand.NewSource(3))
+		cs := make([]Citizen, 200)
+		for i := range cs {
+			cs[i] = randomCitizen(r, 2)
+		}
+		return cs
+	}()
+)
+
+func benchmarkEncode(b *testing.B, v interface{}, encode func(interface{}) ([]byte, error)) {
+	data, err := encode(v)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := encode(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkJSONEncodeCity(b *testing.B)  { benchmarkEncode(b, benchCity, JSONEncode) }
+func BenchmarkStdlibCity(b *testing.B)      { benchmarkEncode(b, benchCity, json.Marshal) }
+func BenchmarkJSONEncodeLarge(b *testing.B) { benchmarkEncode(b, benchLarge, JSONEncode) }
+func BenchmarkStdlibLarge(b *testing.B)     { benchmarkEncode(b, benchLarge, json.Marshal) }
+func BenchmarkJSONEncodeParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := JSONEncode(benchCity); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,784 @@
+package main
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeError is an error of JSONDecode: a syntax error, a value not fitting
+// its destination, or an unknown field in strict mode.
+type DecodeError struct {
+	// Offset is the byte offset of the value or character at fault.
+	Offset int
+	// Path is the destination of the value, like "Friends[2].Name",
+	// empty for the top-level value.
+	Path string
+	Msg  string
+	// Err is the error of the UnmarshalJSON or UnmarshalText method at fault, if any.
+	Err error
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("json: %s at offset %d", e.Msg, e.Offset)
+	}
+	return fmt.Sprintf("json: %s at offset %d (%s)", e.Msg, e.Offset, e.Path)
+}
+
+// InvalidDecodeError is returned by JSONDecode for a destination which isn't a non-nil pointer.
+type InvalidDecodeError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidDecodeError) Error() string {
+	if e.Type == nil {
+		return "json: JSONDecode(nil)"
+	}
+	if e.Type.Kind() != reflect.Ptr {
+		return "json: JSONDecode(non-pointer " + e.Type.String() + ")"
+	}
+	return "json: JSONDecode(nil " + e.Type.String() + ")"
+}
+
+type DecodeOptions struct {
+	// Strict rejects object keys matching no struct field.
+	Strict bool
+}
+
+// JSONDecode parses data into the value v points to, honoring the same
+// struct tags as JSONEncode. Object keys match field names exactly first,
+// then case-insensitively, like encoding/json; unknown keys are skipped.
+func JSONDecode(data []byte, v interface{}) error {
+	return DecodeOptions{}.Decode(data, v)
+}
+
+// maxDepth limits nesting, so deep input can't overflow the stack.
+const maxDepth = 10000
+
+func (o DecodeOptions) Decode(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidDecodeError{Type: reflect.TypeOf(v)}
+	}
+
+	d := &decoder{data: data, strict: o.Strict}
+	d.skipSpace()
+	if err := d.value(rv.Elem(), false); err != nil {
+		return err
+	}
+	d.skipSpace()
+	if d.pos < len(d.data) {
+		return d.syntaxError("after top-level value")
+	}
+	return nil
+}
+
+type decoder struct {
+	data   []byte
+	pos    int
+	strict bool
+	// path holds the segments of the destination path
+	path  []string
+	depth int
+}
+
+func (d *decoder) error(offset int, format string, args ...interface{}) error {
+	path := strings.TrimPrefix(strings.Join(d.path, ""), ".")
+	return &DecodeError{Offset: offset, Path: path, Msg: fmt.Sprintf(format, args...)}
+}
+
+// syntaxError reports the character at pos, or the unexpected end of input.
+func (d *decoder) syntaxError(context string) error {
+	if d.pos >= len(d.data) {
+		return d.error(d.pos, "unexpected end of JSON input")
+	}
+	return d.error(d.pos, "invalid character %s %s", quoteChar(d.data[d.pos]), context)
+}
+
+func quoteChar(c byte) string {
+	if c == '\'' {
+		return `'\''`
+	}
+	if c == '"' {
+		return `'"'`
+	}
+	s := strconv.Quote(string(rune(c)))
+	return "'" + s[1:len(s)-1] + "'"
+}
+
+func (d *decoder) typeError(offset int, what string, t reflect.Type) error {
+	return d.error(offset, "cannot decode %s into Go value of type %s", what, t)
+}
+
+func (d *decoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\r', '\n':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value decodes the value at pos into v, quoted is the ",string" option.
+func (d *decoder) value(v reflect.Value, quoted bool) error {
+	if d.pos >= len(d.data) {
+		return d.syntaxError("")
+	}
+
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > maxDepth {
+		return d.error(d.pos, "exceeded max depth")
+	}
+
+	c := d.data[d.pos]
+	if quoted && c != '"' && c != 'n' {
+		// like encoding/json, a ",string" field only takes a string or null
+		t := v.Type()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return d.error(d.pos, "invalid use of ,string struct tag, trying to decode unquoted value into %s", t)
+	}
+
+	if c == '"' && quoted {
+		return d.quoted(v)
+	}
+
+	u, tu, pv := indirect(v, c == 'n')
+	if u != nil || tu != nil {
+		return d.unmarshal(v, u, tu)
+	}
+
+	switch {
+	case c == '{':
+		return d.object(pv)
+	case c == '[':
+		return d.array(pv)
+	case c == '"':
+		start := d.pos
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		return d.storeString(start, s, pv)
+	case c == 'n':
+		start := d.pos
+		if err := d.literal("null"); err != nil {
+			return err
+		}
+		return d.storeNull(start, pv)
+	case c == 't' || c == 'f':
+		start := d.pos
+		b := c == 't'
+		lit := "false"
+		if b {
+			lit = "true"
+		}
+		if err := d.literal(lit); err != nil {
+			return err
+		}
+		return d.storeBool(start, b, pv)
+	case c == '-' || '0' <= c && c <= '9':
+		start := d.pos
+		num, err := d.number()
+		if err != nil {
+			return err
+		}
+		return d.storeNumber(start, num, pv)
+	default:
+		return d.syntaxError("looking for beginning of value")
+	}
+}
+
+// quoted decodes a ",string" field: the JSON of a scalar inside a string.
+func (d *decoder) quoted(v reflect.Value) error {
+	start := d.pos
+	s, err := d.string()
+	if err != nil {
+		return err
+	}
+
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	invalid := func() error {
+		return d.error(start, "invalid use of ,string struct tag, trying to decode %q into %s", s, t)
+	}
+	inner := &decoder{data: []byte(s), strict: d.strict, path: d.path}
+	if s != "null" && t.Kind() == reflect.String && t != numberType && (len(s) == 0 || s[0] != '"') {
+		return invalid()
+	}
+	if err := inner.value(v, false); err != nil {
+		return invalid()
+	}
+	inner.skipSpace()
+	if inner.pos < len(inner.data) {
+		return invalid()
+	}
+	return nil
+}
+
+// unmarshal hands the value at pos to the Unmarshaler u, or the string at
+// pos to the TextUnmarshaler tu, v is the destination they were found on.
+func (d *decoder) unmarshal(v reflect.Value, u Unmarshaler, tu encoding.TextUnmarshaler) error {
+	start := d.pos
+	if u != nil {
+		if err := d.skip(); err != nil {
+			return err
+		}
+		if err := u.UnmarshalJSON(d.data[start:d.pos]); err != nil {
+			return d.hookError(start, "UnmarshalJSON", v.Type(), err)
+		}
+		return nil
+	}
+
+	if c := d.data[d.pos]; c != '"' {
+		if err := d.skip(); err != nil {
+			return err
+		}
+		return d.typeError(start, tokenName(c), v.Type())
+	}
+	s, err := d.string()
+	if err != nil {
+		return err
+	}
+	if err := tu.UnmarshalText([]byte(s)); err != nil {
+		return d.hookError(start, "UnmarshalText", v.Type(), err)
+	}
+	return nil
+}
+
+func (d *decoder) hookError(offset int, method string, t reflect.Type, err error) error {
+	de := d.error(offset, "error calling %s for type %s: %v", method, t, err).(*DecodeError)
+	de.Err = err
+	return de
+}
+
+func (d *decoder) literal(lit string) error {
+	for i := 0; i < len(lit); i++ {
+		if d.pos >= len(d.data) || d.data[d.pos] != lit[i] {
+			return d.syntaxError("in literal " + lit + " (expecting " + quoteChar(lit[i]) + ")")
+		}
+		d.pos++
+	}
+	return nil
+}
+
+// number scans a number by the JSON grammar and returns its text.
+func (d *decoder) number() (string, error) {
+	start := d.pos
+	if d.data[d.pos] == '-' {
+		d.pos++
+	}
+	switch {
+	case d.pos < len(d.data) && d.data[d.pos] == '0':
+		d.pos++
+	case d.pos < len(d.data) && '1' <= d.data[d.pos] && d.data[d.pos] <= '9':
+		d.digits()
+	default:
+		return "", d.syntaxError("in numeric literal")
+	}
+	if d.pos < len(d.data) && d.data[d.pos] == '.' {
+		d.pos++
+		if d.digits() == 0 {
+			return "", d.syntaxError("after decimal point in numeric literal")
+		}
+	}
+	if d.pos < len(d.data) && (d.data[d.pos] == 'e' || d.data[d.pos] == 'E') {
+		d.pos++
+		if d.pos < len(d.data) && (d.data[d.pos] == '+' || d.data[d.pos] == '-') {
+			d.pos++
+		}
+		if d.digits() == 0 {
+			return "", d.syntaxError("in exponent of numeric literal")
+		}
+	}
+	return string(d.data[start:d.pos]), nil
+}
+
+func (d *decoder) digits() int {
+	n := 0
+	for d.pos < len(d.data) && '0' <= d.data[d.pos] && d.data[d.pos] <= '9' {
+		d.pos++
+		n++
+	}
+	return n
+}
+
+// string scans a quoted string and returns it unescaped. Invalid UTF-8 and
+// lone surrogates become U+FFFD.
+func (d *decoder) string() (string, error) {
+	d.pos++ // opening quote
+	var b []byte
+	start := d.pos
+	for {
+		if d.pos >= len(d.data) {
+			return "", d.syntaxError("")
+		}
+		switch c := d.data[d.pos]; {
+		case c == '"':
+			if b == nil {
+				s := string(d.data[start:d.pos])
+				d.pos++
+				if utf8.ValidString(s) {
+					return s, nil
+				}
+				return strings.ToValidUTF8(s, "\ufffd"), nil
+			}
+			b = append(b, d.data[start:d.pos]...)
+			d.pos++
+			return strings.ToValidUTF8(string(b), "\ufffd"), nil
+		case c < ' ':
+			return "", d.syntaxError("in string literal")
+		case c == '\\':
+			b = append(b, d.data[start:d.pos]...)
+			d.pos++
+			if d.pos >= len(d.data) {
+				return "", d.syntaxError("")
+			}
+			switch e := d.data[d.pos]; e {
+			case '"', '\\', '/':
+				b = append(b, e)
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'u':
+				r, err := d.hex4()
+				if err != nil {
+					return "", err
+				}
+				if utf16.IsSurrogate(r) {
+					// a high surrogate must be followed by an escaped low one
+					r2 := utf8.RuneError
+					if d.pos+2 < len(d.data) && d.data[d.pos+1] == '\\' && d.data[d.pos+2] == 'u' {
+						save := d.pos
+						d.pos += 2
+						low, err := d.hex4()
+						if err != nil {
+							return "", err
+						}
+						if dec := utf16.DecodeRune(r, low); dec != utf8.RuneError {
+							r2 = dec
+						} else {
+							d.pos = save
+						}
+					}
+					r = r2
+				}
+				var rb [utf8.UTFMax]byte
+				b = append(b, rb[:utf8.EncodeRune(rb[:], r)]...)
+			default:
+				return "", d.syntaxError("in string escape code")
+			}
+			d.pos++
+			start = d.pos
+		default:
+			d.pos++
+		}
+	}
+}
+
+// hex4 reads the 4 hex digits after \u, leaving pos on the last one.
+func (d *decoder) hex4() (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		d.pos++
+		if d.pos >= len(d.data) {
+			return 0, d.syntaxError("")
+		}
+		c := d.data[d.pos]
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, d.syntaxError("in \\u hexadecimal character escape")
+		}
+		r = r*16 + rune(c)
+	}
+	return r, nil
+}
+
+func (d *decoder) storeNull(offset int, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		v.Set(reflect.Zero(v.Type()))
+	}
+	// null is a no-op for other kinds
+	return nil
+}
+
+func (d *decoder) storeBool(offset int, b bool, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.Bool:
+		v.SetBool(b)
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+		v.Set(reflect.ValueOf(b))
+	default:
+		return d.typeError(offset, "bool", v.Type())
+	}
+	return nil
+}
+
+func (d *decoder) storeString(offset int, s string, v reflect.Value) error {
+	switch {
+	case v.Type() == numberType:
+		if !isValidNumber(s) {
+			return d.error(offset, "invalid number literal, trying to decode %q into Number", s)
+		}
+		v.SetString(s)
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+		v.Set(reflect.ValueOf(s))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		// []byte is base64, as JSONEncode writes it
+		b := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
+		n, err := base64.StdEncoding.Decode(b, []byte(s))
+		if err != nil {
+			return d.error(offset, "cannot decode string into Go value of type %s: %v", v.Type(), err)
+		}
+		v.SetBytes(b[:n])
+	default:
+		return d.typeError(offset, "string", v.Type())
+	}
+	return nil
+}
+
+func (d *decoder) storeNumber(offset int, num string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(num, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return d.typeError(offset, "number "+num, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil || v.OverflowUint(n) {
+			return d.typeError(offset, "number "+num, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(num, v.Type().Bits())
+		if err != nil || v.OverflowFloat(f) {
+			return d.typeError(offset, "number "+num, v.Type())
+		}
+		v.SetFloat(f)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError(offset, "number", v.Type())
+		}
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return d.typeError(offset, "number "+num, v.Type())
+		}
+		v.Set(reflect.ValueOf(f))
+	case reflect.String:
+		if v.Type() != numberType {
+			return d.typeError(offset, "number", v.Type())
+		}
+		v.SetString(num)
+	default:
+		return d.typeError(offset, "number", v.Type())
+	}
+	return nil
+}
+
+func (d *decoder) array(v reflect.Value) error {
+	start := d.pos
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError(start, "array", v.Type())
+		}
+		s := reflect.ValueOf(&[]interface{}{}).Elem()
+		if err := d.array(s); err != nil {
+			return err
+		}
+		v.Set(s)
+		return nil
+	case reflect.Slice, reflect.Array:
+	default:
+		return d.typeError(start, "array", v.Type())
+	}
+
+	d.pos++ // [
+	i := 0
+	d.skipSpace()
+	if d.pos < len(d.data) && d.data[d.pos] == ']' {
+		d.pos++
+	} else {
+		for {
+			if v.Kind() == reflect.Slice && i >= v.Len() {
+				if i >= v.Cap() {
+					v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+				} else {
+					v.SetLen(i + 1)
+				}
+			}
+
+			d.path = append(d.path, "["+strconv.Itoa(i)+"]")
+			var err error
+			if i < v.Len() {
+				// like encoding/json, elements within the capacity are decoded
+				// into, not replaced
+				err = d.value(v.Index(i), false)
+			} else {
+				// extra elements of arrays are dropped
+				err = d.value(reflect.New(v.Type().Elem()).Elem(), false)
+			}
+			d.path = d.path[:len(d.path)-1]
+			if err != nil {
+				return err
+			}
+			i++
+
+			d.skipSpace()
+			if d.pos >= len(d.data) {
+				return d.syntaxError("")
+			}
+			if d.data[d.pos] == ']' {
+				d.pos++
+				break
+			}
+			if d.data[d.pos] != ',' {
+				return d.syntaxError("after array element")
+			}
+			d.pos++
+			d.skipSpace()
+		}
+	}
+
+	switch {
+	case v.Kind() == reflect.Array:
+		for ; i < v.Len(); i++ {
+			v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+		}
+	case i < v.Len():
+		v.SetLen(i)
+	case v.IsNil():
+		// [] is an empty slice, not a nil one
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	}
+	return nil
+}
+
+func (d *decoder) object(v reflect.Value) error {
+	start := d.pos
+	var fields []field
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError(start, "object", v.Type())
+		}
+		m := reflect.ValueOf(&map[string]interface{}{}).Elem()
+		if err := d.object(m); err != nil {
+			return err
+		}
+		v.Set(m)
+		return nil
+	case reflect.Map:
+		switch v.Type().Key().Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !reflect.PtrTo(v.Type().Key()).Implements(textUnmarshalerType) {
+				return d.typeError(start, "object", v.Type())
+			}
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+	case reflect.Struct:
+		fields = cachedTypeFields(v.Type(), "json")
+	default:
+		return d.typeError(start, "object", v.Type())
+	}
+
+	d.pos++ // {
+	d.skipSpace()
+	if d.pos < len(d.data) && d.data[d.pos] == '}' {
+		d.pos++
+		return nil
+	}
+	for {
+		if d.pos >= len(d.data) || d.data[d.pos] != '"' {
+			return d.syntaxError("looking for beginning of object key string")
+		}
+		keyStart := d.pos
+		key, err := d.string()
+		if err != nil {
+			return err
+		}
+		d.skipSpace()
+		if d.pos >= len(d.data) || d.data[d.pos] != ':' {
+			return d.syntaxError("after object key")
+		}
+		d.pos++
+		d.skipSpace()
+
+		if v.Kind() == reflect.Map {
+			err = d.mapEntry(keyStart, key, v)
+		} else {
+			err = d.structField(keyStart, key, v, fields)
+		}
+		if err != nil {
+			return err
+		}
+
+		d.skipSpace()
+		if d.pos >= len(d.data) {
+			return d.syntaxError("")
+		}
+		if d.data[d.pos] == '}' {
+			d.pos++
+			return nil
+		}
+		if d.data[d.pos] != ',' {
+			return d.syntaxError("after object key:value pair")
+		}
+		d.pos++
+		d.skipSpace()
+	}
+}
+
+func (d *decoder) mapEntry(keyStart int, key string, m reflect.Value) error {
+	kt := m.Type().Key()
+	k := reflect.New(kt).Elem()
+	if tu, ok := k.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		// like encoding/json, keys decoding themselves do so even if they're strings
+		if err := tu.UnmarshalText([]byte(key)); err != nil {
+			return d.hookError(keyStart, "UnmarshalText", kt, err)
+		}
+	} else if err := d.mapKey(keyStart, key, k); err != nil {
+		return err
+	}
+
+	d.path = append(d.path, pathKey(key))
+	defer func() { d.path = d.path[:len(d.path)-1] }()
+
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := d.value(elem, false); err != nil {
+		return err
+	}
+	m.SetMapIndex(k, elem)
+	return nil
+}
+
+// mapKey parses the object key for a string or integer map key.
+func (d *decoder) mapKey(offset int, key string, k reflect.Value) error {
+	switch k.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(n) {
+			return d.typeError(offset, "number "+key, k.Type())
+		}
+		k.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(n) {
+			return d.typeError(offset, "number "+key, k.Type())
+		}
+		k.SetUint(n)
+	}
+	return nil
+}
+
+func (d *decoder) structField(keyStart int, key string, v reflect.Value, fields []field) error {
+	f := matchField(fields, key)
+
+	d.path = append(d.path, pathKey(key))
+	defer func() { d.path = d.path[:len(d.path)-1] }()
+
+	if f == nil {
+		if d.strict {
+			return d.error(keyStart, "unknown field %q", key)
+		}
+		return d.skip()
+	}
+
+	fv, err := d.settableField(keyStart, v, f.index)
+	if err != nil {
+		return err
+	}
+	return d.value(fv, f.quoted)
+}
+
+// matchField returns the field named key, or else the first one matching it
+// case-insensitively.
+func matchField(fields []field, key string) *field {
+	for i := range fields {
+		if fields[i].name == key {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, key) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// settableField follows the index path, allocating the nil embedded struct pointers.
+func (d *decoder) settableField(offset int, v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, d.error(offset, "cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// skip scans over the value at pos, checking its syntax.
+func (d *decoder) skip() error {
+	var discard interface{}
+	return d.value(reflect.ValueOf(&discard).Elem(), false)
+}
+
+// pathKey is the path segment of an object key, keys which aren't
+// identifiers are quoted.
+func pathKey(key string) string {
+	for i, r := range key {
+		if r != '_' && !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+			return "[" + strconv.Quote(key) + "]"
+		}
+	}
+	if key == "" {
+		return `[""]`
+	}
+	return "." + key
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJSONDecode(t *testing.T) {
+	var u User
+	if err := JSONDecode([]byte(` {"Name": "bob", "age": 10, "Unknown": [1, {"x": null}]} `), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u != (User{"bob", 10}) {
+		t.Errorf("unexpected user %+v", u)
+	}
+
+	var tagged Tagged
+	data := `{"name":"n","age":3,"Skip":"s","-":"d","quoted":"42","QStr":"\"<q>\"","QFloat":"1.5","QBool":"true",
+		"QPtr":"7","QNilPtr":"null","opts":"\"o\""}`
+	if err := JSONDecode([]byte(data), &tagged); err != nil {
+		t.Fatal(err)
+	}
+	var want Tagged
+	if err := json.Unmarshal([]byte(data), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tagged, want) {
+		t.Errorf("expected %+v, got %+v", want, tagged)
+	}
+
+	var p Promoted
+	if err := JSONDecode([]byte(`{"ID":1,"Created":"today","id":"audit","Visible":"v","named":{"Name":"n"},"Name":"outer"}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Base.ID != 1 || p.Audit == nil || p.Audit.Created != "today" || p.Audit.ID != "audit" || p.Visible != "v" ||
+		p.Named.Name != "n" || p.Name != "outer" {
+		t.Errorf("unexpected promoted fields %+v %+v", p, p.Audit)
+	}
+
+	var generic interface{}
+	if err := JSONDecode([]byte(`{"a":[1,"\u00e9\ud83d\ude00\ud800",true,null,{}],"b":-1.5e3}`), &generic); err != nil {
+		t.Fatal(err)
+	}
+	wantAny := map[string]interface{}{"a": []interface{}{1.0, "é😀\ufffd", true, nil, map[string]interface{}{}}, "b": -1500.0}
+	if !reflect.DeepEqual(generic, wantAny) {
+		t.Errorf("expected %#v, got %#v", wantAny, generic)
+	}
+
+	arr := [2]int{7, 7}
+	if err := JSONDecode([]byte(`[1]`), &arr); err != nil || arr != [2]int{1, 0} {
+		t.Errorf("expected [1 0], got %v %v", arr, err)
+	}
+	ptr := &User{}
+	if err := JSONDecode([]byte(`null`), &ptr); err != nil || ptr != nil {
+		t.Errorf("expected null to reset the pointer, got %v %v", ptr, err)
+	}
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	for _, tt := range []struct {
+		data   string
+		v      interface{}
+		strict bool
+		want   string
+	}{
+		{`{"Name":"bob","Age":"x"}`, &User{}, false,
+			`json: cannot decode string into Go value of type int64 at offset 20 (Age)`},
+		{`{"Name" "bob"}`, &User{}, false,
+			`json: invalid character '"' after object key at offset 8`},
+		{`{"Name":`, &User{}, false,
+			`json: unexpected end of JSON input at offset 8 (Name)`},
+		{`{"Friends":[{"Name":"a"},{"Age":1.5}]}`, &Citizen{}, false,
+			`json: cannot decode number 1.5 into Go value of type int64 at offset 32 (Friends[1].Age)`},
+		{`{"Scores":{"a b":"x"}}`, &Citizen{}, false,
+			`json: cannot decode string into Go value of type int at offset 17 (Scores["a b"])`},
+		{`{"Cities":{"x":{}}}`, &Citizen{}, false,
+			`json: cannot decode number x into Go value of type int64 at offset 11 (Cities)`},
+		{`{"Code":300}`, &Citizen{}, false,
+			`json: cannot decode number 300 into Go value of type int8 at offset 8 (Code)`},
+		{`{"Name":"bob","Nick":"b"}`, &User{}, true,
+			`json: unknown field "Nick" at offset 14 (Nick)`},
+		{`{"Name":"bob","Nick":"b"}`, &User{}, false, ``},
+		{`{"Tags":["a",]}`, &Citizen{}, false,
+			`json: invalid character ']' looking for beginning of value at offset 13 (Tags[1])`},
+		{`[01]`, &[]int{}, false,
+			`json: invalid character '1' after array element at offset 2`},
+		{`"\x"`, new(string), false,
+			`json: invalid character 'x' in string escape code at offset 2`},
+		{"\"a\tb\"", new(string), false,
+			`json: invalid character '\t' in string literal at offset 2`},
+		{`{} x`, &User{}, false,
+			`json: invalid character 'x' after top-level value at offset 3`},
+		{`tru`, new(bool), false,
+			`json: unexpected end of JSON input at offset 3`},
+		{`{"quoted":"x"}`, &Tagged{}, false,
+			`json: invalid use of ,string struct tag, trying to decode "x" into int at offset 10 (quoted)`},
+		{`{"quoted":12}`, &Tagged{}, false,
+			`json: invalid use of ,string struct tag, trying to decode unquoted value into int at offset 10 (quoted)`},
+		{`{"QPtr":[7]}`, &Tagged{}, false,
+			`json: invalid use of ,string struct tag, trying to decode unquoted value into int at offset 8 (QPtr)`},
+		{`{"QBool":true}`, &Tagged{}, false,
+			`json: invalid use of ,string struct tag, trying to decode unquoted value into bool at offset 9 (QBool)`},
+		{`{"quoted":null,"QPtr":null}`, &Tagged{}, false, ``},
+		{`{"Temp":{"Celsius":"hot"}}`, &RoundTrip{}, false,
+			`json: error calling UnmarshalJSON for type main.Celsius: json: cannot unmarshal string into Go struct field .Celsius of type float64 at offset 8 (Temp)`},
+		{`{"Color":"pink"}`, &RoundTrip{}, false,
+			`json: error calling UnmarshalText for type main.Color: unknown color pink at offset 9 (Color)`},
+		{`{"Color":2}`, &RoundTrip{}, false,
+			`json: cannot decode number into Go value of type main.Color at offset 9 (Color)`},
+		{`{"Colors":{"pink":1}}`, &RoundTrip{}, false,
+			`json: error calling UnmarshalText for type main.Color: unknown color pink at offset 11 (Colors)`},
+		{`{"When":"yesterday"}`, &RoundTrip{}, false,
+			`json: error calling UnmarshalJSON for type time.Time: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006" at offset 8 (When)`},
+		{`{"Data":"!!"}`, &RoundTrip{}, false,
+			`json: cannot decode string into Go value of type []uint8: illegal base64 data at input byte 0 at offset 8 (Data)`},
+		{`{"Number":"12x"}`, &RoundTrip{}, false,
+			`json: invalid number literal, trying to decode "12x" into Number at offset 10 (Number)`},
+		{`{"QNumber":"\"x\""}`, &RoundTrip{}, false,
+			`json: invalid use of ,string struct tag, trying to decode "\"x\"" into json.Number at offset 11 (QNumber)`},
+		{`{"x":1}`, User{}, false,
+			`json: JSONDecode(non-pointer main.User)`},
+		{`{"x":1}`, nil, false,
+			`json: JSONDecode(nil)`},
+	} {
+		err := DecodeOptions{Strict: tt.strict}.Decode([]byte(tt.data), tt.v)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%s:\nexpected %s\ngot      %s", tt.data, tt.want, got)
+		}
+	}
+}
+
+// TestJSONDecodeDifferential compares JSONDecode with encoding/json on random documents.
+func TestJSONDecodeDifferential(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 2000; i++ {
+		data, err := JSONEncode(randomCitizen(r, 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got, want Citizen
+		if err := json.Unmarshal(data, &want); err != nil {
+			t.Fatal(err)
+		}
+		if err := JSONDecode(data, &got); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s:\nexpected %#v\ngot      %#v", data, want, got)
+		}
+	}
+}
+
+// RoundTrip has the types JSONEncode has special cases for.
+type RoundTrip struct {
+	Temp     Celsius
+	TempPtr  *Celsius
+	Point    Point
+	PointPtr *Point
+	Points   []Point
+	Color    Color
+	Colors   map[Color]int
+	When     time.Time
+	WhenPtr  *time.Time
+	Data     []byte
+	Empty    []byte
+	Nil      []byte
+	Fixed    [3]byte
+	Blobs    map[string][]byte
+	Number   json.Number
+	Numbers  []json.Number
+	QNumber  json.Number `json:",string"`
+	Any      interface{}
+}
+
+func TestJSONDecodeRoundTrip(t *testing.T) {
+	temp := Celsius(-3.5)
+	when := time.Date(2021, 6, 1, 12, 30, 0, 123456789, time.UTC)
+	v := RoundTrip{
+		Temp:     36.6,
+		TempPtr:  &temp,
+		Point:    Point{1, 2},
+		PointPtr: &Point{3, 4},
+		Points:   []Point{{5, 6}, {7, 8}},
+		Color:    2,
+		Colors:   map[Color]int{0: 1, 1: 2},
+		When:     when,
+		WhenPtr:  &when,
+		Data:     []byte("hello, \x00world"),
+		Empty:    []byte{},
+		Fixed:    [3]byte{1, 2, 3},
+		Blobs:    map[string][]byte{"a": {0xff}, "b": nil},
+		Number:   "-1.5e300",
+		Numbers:  []json.Number{"0", "12345678901234567890"},
+		QNumber:  "42",
+		Any:      []interface{}{"x", 1.5, true, nil},
+	}
+
+	// addressable, so Point encodes itself
+	data, err := JSONEncode(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want RoundTrip
+	if err := JSONDecode(data, &got); err != nil {
+		t.Fatalf("%s: %v", data, err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("%s:\nexpected %#v\ngot      %#v", data, v, got)
+	}
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: encoding/json decodes\n%#v\ngot\n%#v", data, want, got)
+	}
+
+	// null keeps the values decoding themselves, it resets the pointers
+	null := []byte(`{"Temp":null,"TempPtr":null,"Color":null,"When":null,"WhenPtr":null,"Data":null}`)
+	if err := JSONDecode(null, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Temp != v.Temp || got.TempPtr != nil || got.Color != v.Color || !got.When.Equal(when) || got.WhenPtr != nil || got.Data != nil {
+		t.Errorf("unexpected values after null: %#v", got)
+	}
+	if err := json.Unmarshal(null, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after null, encoding/json decodes\n%#v\ngot\n%#v", want, got)
+	}
+}
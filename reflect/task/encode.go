@@ -0,0 +1,686 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// UnsupportedTypeError is returned by JSONEncode for values without a JSON
+// representation, like channels and functions.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "json: unsupported type: " + e.Type.String()
+}
+
+// UnsupportedValueError is returned by JSONEncode for values of supported
+// types which can't be encoded, like NaN.
+type UnsupportedValueError struct {
+	Value reflect.Value
+	Str   string
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return "json: unsupported value: " + e.Str
+}
+
+// JSONEncode returns the JSON encoding of v, the same encoding/json.Marshal does.
+//
+// The encoder of a type is built once, on its first encoding, and kept in
+// encoderCache. It resolves the struct fields and prepares their encoded
+// keys up front, so encoding only walks the value.
+//
+// Values implementing Marshaler or encoding.TextMarshaler encode themselves,
+// time.Time is written in RFC 3339 and []byte in base64. Pointer cycles are
+// reported as an UnsupportedValueError.
+func JSONEncode(v interface{}) ([]byte, error) {
+	return EncodeOptions{}.Encode(v)
+}
+
+// EncodeOptions tune the output of JSONEncode, the zero value gives the
+// output of encoding/json.Marshal.
+type EncodeOptions struct {
+	// Prefix and Indent put every element of objects and arrays on its own
+	// line, beginning with Prefix and nested copies of Indent, as json.MarshalIndent.
+	Prefix, Indent string
+	// UnsortedKeys writes map entries in iteration order instead of sorting them by key.
+	UnsortedKeys bool
+}
+
+func (o EncodeOptions) Encode(v interface{}) ([]byte, error) {
+	e := newEncodeState()
+	defer encodeStatePool.Put(e)
+
+	e.sortKeys = !o.UnsortedKeys
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	if o.Prefix != "" || o.Indent != "" {
+		var buf bytes.Buffer
+		indent(&buf, e.Bytes(), o.Prefix, o.Indent)
+		return buf.Bytes(), nil
+	}
+	// the buffer goes back to the pool, so the result is a copy
+	return append([]byte(nil), e.Bytes()...), nil
+}
+
+type encodeState struct {
+	bytes.Buffer
+	// scratch is for formatting numbers without allocating
+	scratch  [64]byte
+	sortKeys bool
+
+	// ptrLevel counts the nested pointers, maps and slices, past
+	// startDetectingCycles the ones on the way are kept in ptrSeen.
+	ptrLevel int
+	ptrSeen  map[ptrKey]struct{}
+}
+
+// startDetectingCycles is deep enough for the values without cycles
+// to never pay for the detection.
+const startDetectingCycles = 1000
+
+// ptrKey identifies the target of a pointer, a map or a slice, slices of
+// different lengths at the same address aren't a cycle.
+type ptrKey struct {
+	ptr uintptr
+	len int
+}
+
+var encodeStatePool sync.Pool
+
+func newEncodeState() *encodeState {
+	if v := encodeStatePool.Get(); v != nil {
+		e := v.(*encodeState)
+		e.Reset()
+		e.ptrLevel = 0
+		for k := range e.ptrSeen {
+			delete(e.ptrSeen, k)
+		}
+		return e
+	}
+	return &encodeState{}
+}
+
+// enter registers one more level of nesting through v, it fails when v
+// is already on the way, unenter undoes it.
+func (e *encodeState) enter(v reflect.Value, len int) error {
+	if e.ptrLevel++; e.ptrLevel <= startDetectingCycles {
+		return nil
+	}
+	if e.ptrSeen == nil {
+		e.ptrSeen = make(map[ptrKey]struct{})
+	}
+	k := ptrKey{v.Pointer(), len}
+	if _, ok := e.ptrSeen[k]; ok {
+		return &UnsupportedValueError{Value: v, Str: "encountered a cycle via " + v.Type().String()}
+	}
+	e.ptrSeen[k] = struct{}{}
+	return nil
+}
+
+func (e *encodeState) unenter(v reflect.Value, len int) {
+	if e.ptrLevel--; e.ptrLevel >= startDetectingCycles {
+		delete(e.ptrSeen, ptrKey{v.Pointer(), len})
+	}
+}
+
+func (e *encodeState) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.WriteString("null")
+		return nil
+	}
+	return typeEncoder(v.Type())(e, v)
+}
+
+type encoderFunc func(e *encodeState, v reflect.Value) error
+
+// encoderCache is a map[reflect.Type]encoderFunc.
+var encoderCache sync.Map
+
+func typeEncoder(t reflect.Type) encoderFunc {
+	if f, ok := encoderCache.Load(t); ok {
+		return f.(encoderFunc)
+	}
+
+	// A recursive type reaches typeEncoder again while its encoder is being
+	// built, so an indirect encoder waiting for the real one is cached first.
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	fi, loaded := encoderCache.LoadOrStore(t, encoderFunc(func(e *encodeState, v reflect.Value) error {
+		wg.Wait()
+		return f(e, v)
+	}))
+	if loaded {
+		return fi.(encoderFunc)
+	}
+
+	f = newTypeEncoder(t, true)
+	wg.Done()
+	encoderCache.Store(t, f)
+	return f
+}
+
+func newTypeEncoder(t reflect.Type, allowAddr bool) encoderFunc {
+	if t == timeType {
+		return timeEncoder
+	}
+	if t == numberType {
+		return numberEncoder
+	}
+	// Methods with pointer receivers are reachable for addressable values
+	// only, like the elements of slices and the fields behind pointers.
+	if t.Kind() != reflect.Ptr && allowAddr && reflect.PtrTo(t).Implements(marshalerType) {
+		return newCondAddrEncoder(addrMarshalerEncoder, newTypeEncoder(t, false))
+	}
+	if t.Implements(marshalerType) {
+		return marshalerEncoder
+	}
+	if t.Kind() != reflect.Ptr && allowAddr && reflect.PtrTo(t).Implements(textMarshalerType) {
+		return newCondAddrEncoder(addrTextMarshalerEncoder, newTypeEncoder(t, false))
+	}
+	if t.Implements(textMarshalerType) {
+		return textMarshalerEncoder
+	}
+	return newKindEncoder(t)
+}
+
+func newKindEncoder(t reflect.Type) encoderFunc {
+	switch t.Kind() {
+	case reflect.String:
+		return stringEncoder
+	case reflect.Bool:
+		return boolEncoder
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intEncoder
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uintEncoder
+	case reflect.Float32, reflect.Float64:
+		return floatEncoder
+	case reflect.Interface:
+		return interfaceEncoder
+	case reflect.Ptr:
+		return newPtrEncoder(t)
+	case reflect.Struct:
+		return newStructEncoder(t)
+	case reflect.Map:
+		return newMapEncoder(t)
+	case reflect.Slice:
+		return newSliceEncoder(t)
+	case reflect.Array:
+		return newArrayEncoder(t)
+	default:
+		return func(e *encodeState, v reflect.Value) error {
+			return &UnsupportedTypeError{Type: v.Type()}
+		}
+	}
+}
+
+func stringEncoder(e *encodeState, v reflect.Value) error {
+	writeString(&e.Buffer, v.String())
+	return nil
+}
+
+// numberType is encoding/json's Number, a string holding a number literal
+// which is written as is.
+var numberType = reflect.TypeOf(json.Number(""))
+
+func numberEncoder(e *encodeState, v reflect.Value) error {
+	num := v.String()
+	if num == "" {
+		num = "0"
+	}
+	if !isValidNumber(num) {
+		return fmt.Errorf("json: invalid number literal %q", num)
+	}
+	e.WriteString(num)
+	return nil
+}
+
+// isValidNumber tells whether s is a JSON number literal.
+func isValidNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	if s[0] == '-' {
+		s = s[1:]
+	}
+	digits := func() bool {
+		n := 0
+		for n < len(s) && '0' <= s[n] && s[n] <= '9' {
+			n++
+		}
+		s = s[n:]
+		return n > 0
+	}
+
+	switch {
+	case s == "":
+		return false
+	case s[0] == '0':
+		s = s[1:]
+	case !digits():
+		return false
+	}
+	if s != "" && s[0] == '.' {
+		s = s[1:]
+		if !digits() {
+			return false
+		}
+	}
+	if s != "" && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if s != "" && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return s == ""
+}
+
+func boolEncoder(e *encodeState, v reflect.Value) error {
+	if v.Bool() {
+		e.WriteString("true")
+	} else {
+		e.WriteString("false")
+	}
+	return nil
+}
+
+func intEncoder(e *encodeState, v reflect.Value) error {
+	e.Write(strconv.AppendInt(e.scratch[:0], v.Int(), 10))
+	return nil
+}
+
+func uintEncoder(e *encodeState, v reflect.Value) error {
+	e.Write(strconv.AppendUint(e.scratch[:0], v.Uint(), 10))
+	return nil
+}
+
+func interfaceEncoder(e *encodeState, v reflect.Value) error {
+	if v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+	return e.encode(v.Elem())
+}
+
+func newPtrEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	return func(e *encodeState, v reflect.Value) error {
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		if err := e.enter(v, 0); err != nil {
+			return err
+		}
+		if err := elem(e, v.Elem()); err != nil {
+			return err
+		}
+		e.unenter(v, 0)
+		return nil
+	}
+}
+
+// structField is a field of a struct encoder.
+type structField struct {
+	field
+	// key is the encoded name with the colon
+	key []byte
+	enc encoderFunc
+}
+
+func newStructEncoder(t reflect.Type) encoderFunc {
+	fields := cachedTypeFields(t, "json")
+	sfs := make([]structField, len(fields))
+	for i, f := range fields {
+		var key bytes.Buffer
+		writeString(&key, f.name)
+		key.WriteByte(':')
+
+		ft := typeByIndex(t, f.index)
+		enc := typeEncoder(ft)
+		if f.quoted {
+			enc = newQuotedEncoder(ft, enc)
+		}
+		sfs[i] = structField{field: f, key: key.Bytes(), enc: enc}
+	}
+
+	return func(e *encodeState, v reflect.Value) error {
+		e.WriteByte('{')
+		first := true
+		for i := range sfs {
+			f := &sfs[i]
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			if !first {
+				e.WriteByte(',')
+			}
+			first = false
+			e.Write(f.key)
+			if err := f.enc(e, fv); err != nil {
+				return err
+			}
+		}
+		e.WriteByte('}')
+		return nil
+	}
+}
+
+func typeByIndex(t reflect.Type, index []int) reflect.Type {
+	for _, i := range index {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(i).Type
+	}
+	return t
+}
+
+// fieldByIndex returns the field at the index path, it's missing when an
+// embedded struct pointer on the way is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// newQuotedEncoder encodes a ",string" field: the JSON of the value inside a string.
+func newQuotedEncoder(t reflect.Type, enc encoderFunc) encoderFunc {
+	if hasMarshaler(t) {
+		// encoding/json ignores the option for the types encoding themselves
+		return enc
+	}
+	if t.Kind() == reflect.Ptr {
+		elem := newQuotedEncoder(t.Elem(), typeEncoder(t.Elem()))
+		return func(e *encodeState, v reflect.Value) error {
+			if v.IsNil() {
+				e.WriteString("null")
+				return nil
+			}
+			return elem(e, v.Elem())
+		}
+	}
+
+	// a Number is quoted as is, other strings are encoded twice
+	if t.Kind() == reflect.String && t != numberType {
+		return func(e *encodeState, v reflect.Value) error {
+			inner := newEncodeState()
+			defer encodeStatePool.Put(inner)
+			writeString(&inner.Buffer, v.String())
+			writeString(&e.Buffer, inner.String())
+			return nil
+		}
+	}
+	return func(e *encodeState, v reflect.Value) error {
+		e.WriteByte('"')
+		if err := enc(e, v); err != nil {
+			return err
+		}
+		e.WriteByte('"')
+		return nil
+	}
+}
+
+type mapEntry struct {
+	key string
+	// i is the entry's position in the copied keys and values
+	i int
+}
+
+// mapEntries sorts a map's entries by key, it's pooled along with its slice.
+type mapEntries struct{ s []mapEntry }
+
+func (m *mapEntries) Len() int           { return len(m.s) }
+func (m *mapEntries) Less(i, j int) bool { return m.s[i].key < m.s[j].key }
+func (m *mapEntries) Swap(i, j int)      { m.s[i], m.s[j] = m.s[j], m.s[i] }
+
+var mapEntriesPool = sync.Pool{New: func() interface{} { return new(mapEntries) }}
+
+func newMapEncoder(t reflect.Type) encoderFunc {
+	switch t.Key().Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !t.Key().Implements(textMarshalerType) {
+			return func(e *encodeState, v reflect.Value) error {
+				return &UnsupportedTypeError{Type: t.Key()}
+			}
+		}
+	}
+	elem := typeEncoder(t.Elem())
+	textKeys := t.Key().Implements(textMarshalerType)
+
+	return func(e *encodeState, v reflect.Value) error {
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+
+		if err := e.enter(v, 0); err != nil {
+			return err
+		}
+
+		// map values aren't addressable, so the pointer receiver
+		// marshalers of the values don't apply, as in encoding/json
+		values := make([]reflect.Value, 0, v.Len())
+		entries := mapEntriesPool.Get().(*mapEntries)
+		defer func() {
+			entries.s = entries.s[:0]
+			mapEntriesPool.Put(entries)
+		}()
+
+		iter := v.MapRange()
+		for i := 0; iter.Next(); i++ {
+			values = append(values, iter.Value())
+			key, err := mapKey(iter.Key(), textKeys)
+			if err != nil {
+				return err
+			}
+			entries.s = append(entries.s, mapEntry{key: key, i: i})
+		}
+		// encoding/json sorts the keys, so the output is stable
+		if e.sortKeys {
+			sort.Sort(entries)
+		}
+
+		e.WriteByte('{')
+		for i, en := range entries.s {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+			writeString(&e.Buffer, en.key)
+			e.WriteByte(':')
+			if err := elem(e, values[en.i]); err != nil {
+				return err
+			}
+		}
+		e.WriteByte('}')
+		e.unenter(v, 0)
+		return nil
+	}
+}
+
+// mapKey returns the object key for a map key, JSON keys are strings so
+// integer keys are quoted and other keys have to be text marshalers.
+func mapKey(k reflect.Value, text bool) (string, error) {
+	if text {
+		tm := k.Interface().(encoding.TextMarshaler)
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		if err != nil {
+			return "", &MarshalerError{Type: k.Type(), Err: err, sourceFunc: "MarshalText"}
+		}
+		return string(b), nil
+	}
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	default:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+}
+
+func newSliceEncoder(t reflect.Type) encoderFunc {
+	// []byte is base64, unless its elements encode themselves
+	if t.Elem().Kind() == reflect.Uint8 && !hasMarshaler(t.Elem()) {
+		return bytesEncoder
+	}
+	array := newArrayEncoder(t)
+	return func(e *encodeState, v reflect.Value) error {
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		if err := e.enter(v, v.Len()); err != nil {
+			return err
+		}
+		if err := array(e, v); err != nil {
+			return err
+		}
+		e.unenter(v, v.Len())
+		return nil
+	}
+}
+
+func newArrayEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	return func(e *encodeState, v reflect.Value) error {
+		e.WriteByte('[')
+		n := v.Len()
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				e.WriteByte(',')
+			}
+			if err := elem(e, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.WriteByte(']')
+		return nil
+	}
+}
+
+// floatEncoder formats floats like ES6 does, as encoding/json: exponents
+// only for very small and very large numbers.
+func floatEncoder(e *encodeState, v reflect.Value) error {
+	f := v.Float()
+	bits := v.Type().Bits()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return &UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+	e.Write(appendFloat(e.scratch[:0], f, bits))
+	return nil
+}
+
+// appendFloat appends the finite f formatted for floatEncoder.
+func appendFloat(b []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+const hex = "0123456789abcdef"
+
+// writeString writes s quoted, escaping like encoding/json: control
+// characters, the HTML special characters <, > and &, U+2028 and U+2029,
+// and replacing invalid UTF-8 with U+FFFD.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\b':
+				buf.WriteString(`\b`)
+			case '\f':
+				buf.WriteString(`\f`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[b>>4])
+				buf.WriteByte(hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 end lines in JavaScript
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
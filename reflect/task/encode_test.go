@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+type Address struct {
+	Street string
+	Zip    uint16
+	Geo    [2]float64
+}
+
+type Citizen struct {
+	Person  User
+	Height  float32
+	Admin   bool
+	Tags    []string
+	Scores  map[string]int
+	Home    *Address
+	Extra   interface{}
+	Friends []User
+	Cities  map[int64]City
+	Ratio   float64
+	Code    int8
+	Big     uint64
+	private string
+}
+
+func TestJSONEncode(t *testing.T) {
+	for _, v := range []interface{}{
+		nil,
+		User{"bob", 10},
+		City{"sf", 5000000, 567896, "mr jones"},
+		&User{"ptr", -1},
+		(*User)(nil),
+		[]User(nil),
+		[]User{},
+		map[string]int(nil),
+		map[uint8]string{3: "c", 1: "a", 20: "t"},
+		[3]int{1, 2, 3},
+		[]interface{}{1, "two", 3.5, nil, true, []int{4}},
+		"<a href=\"x\">&amp;</a>\n\t\b\f\x00\x1f\u2028\u2029\xff\xfe ok",
+		float32(0.1),
+		1e21,
+		1e-7,
+		-0.0,
+		123456789.0,
+		float32(1e-7),
+		Citizen{Person: User{"c", 1}, Scores: map[string]int{"b": 2, "a": 1}, Home: &Address{Geo: [2]float64{1.5, -2}}},
+		json.Number("3"),
+		[]json.Number{"0", "-0.5", "1e10", "12.50E-3", ""},
+		map[json.Number]json.Number{"2": "1", "1": "2"},
+		struct {
+			N  json.Number `json:",string"`
+			P  *json.Number
+			NP *json.Number `json:",string"`
+		}{N: "42", NP: new(json.Number)},
+	} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := JSONEncode(v)
+		if err != nil {
+			t.Errorf("%#v: %v", v, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%#v:\nexpected %s\ngot      %s", v, want, got)
+		}
+	}
+}
+
+func TestJSONEncodeErrors(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{make(chan int), "json: unsupported type: chan int"},
+		{func() {}, "json: unsupported type: func()"},
+		{map[float64]int{1: 1}, "json: unsupported type: float64"},
+		{math.NaN(), "json: unsupported value: NaN"},
+		{[]interface{}{math.Inf(-1)}, "json: unsupported value: -Inf"},
+		{json.Number("1x"), `json: invalid number literal "1x"`},
+		{[]json.Number{"01"}, `json: invalid number literal "01"`},
+		{json.Number("1."), `json: invalid number literal "1."`},
+		{json.Number("+1"), `json: invalid number literal "+1"`},
+		{json.Number("1e"), `json: invalid number literal "1e"`},
+	} {
+		if _, err := JSONEncode(tt.v); err == nil || err.Error() != tt.want {
+			t.Errorf("%T: expected %q, got %v", tt.v, tt.want, err)
+		}
+	}
+}
+
+// Tree refers to itself, its encoder is built while it's being built.
+type Tree struct {
+	Value    int
+	Children []*Tree
+	Parent   *Tree `json:",omitempty"`
+}
+
+func TestJSONEncodeConcurrent(t *testing.T) {
+	v := &Tree{Value: 1, Children: []*Tree{{Value: 2}, {Value: 3, Children: []*Tree{{Value: 4}}}}}
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				got, err := JSONEncode(v)
+				if err != nil || string(got) != string(want) {
+					t.Errorf("expected %s, got %s, %v", want, got, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestJSONEncodeDifferential compares JSONEncode with encoding/json on random values.
+func TestJSONEncodeDifferential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		v := randomCitizen(r, 2)
+
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := JSONEncode(v)
+		if err != nil {
+			t.Fatalf("%#v: %v", v, err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%#v:\nexpected %s\ngot      %s", v, want, got)
+		}
+	}
+}
+
+func randomString(r *rand.Rand) string {
+	const alphabet = "abcXYZ019 \"\\/<>&\n\r\t\b\f\x00\x1f\x7fé世\u2028\u2029😀"
+	chars := []rune(alphabet)
+	b := make([]byte, 0, 16)
+	for n := r.Intn(12); n > 0; n-- {
+		if r.Intn(20) == 0 {
+			// invalid UTF-8
+			b = append(b, byte(0x80+r.Intn(0x80)))
+			continue
+		}
+		b = append(b, string(chars[r.Intn(len(chars))])...)
+	}
+	return string(b)
+}
+
+func randomFloat(r *rand.Rand) float64 {
+	switch r.Intn(5) {
+	case 0:
+		return 0
+	case 1:
+		return r.NormFloat64()
+	case 2:
+		return float64(r.Int63()) * math.Pow(10, float64(r.Intn(60)-30))
+	case 3:
+		return -math.Pow(10, float64(r.Intn(600)-300)) * r.Float64()
+	default:
+		return float64(r.Intn(1000)) / 8
+	}
+}
+
+func randomFloat32(r *rand.Rand) float32 {
+	for {
+		if f := math.Float32frombits(r.Uint32()); !math.IsInf(float64(f), 0) && !math.IsNaN(float64(f)) {
+			return f
+		}
+	}
+}
+
+func randomUser(r *rand.Rand) User {
+	return User{Name: randomString(r), Age: r.Int63() - r.Int63()}
+}
+
+func randomCity(r *rand.Rand) City {
+	return City{Name: randomString(r), Population: r.Int63(), GDP: -r.Int63n(1000), Mayor: randomString(r)}
+}
+
+func randomInterface(r *rand.Rand, depth int) interface{} {
+	switch n := r.Intn(8); {
+	case n == 0:
+		return nil
+	case n == 1:
+		return randomString(r)
+	case n == 2:
+		return randomFloat(r)
+	case n == 3:
+		return r.Intn(2) == 0
+	case n == 4:
+		return randomCity(r)
+	case depth > 0 && n == 5:
+		s := make([]interface{}, r.Intn(4))
+		for i := range s {
+			s[i] = randomInterface(r, depth-1)
+		}
+		return s
+	case depth > 0 && n == 6:
+		m := make(map[string]interface{})
+		for i := r.Intn(4); i > 0; i-- {
+			m[randomString(r)] = randomInterface(r, depth-1)
+		}
+		return m
+	default:
+		u := randomUser(r)
+		return &u
+	}
+}
+
+func randomCitizen(r *rand.Rand, depth int) Citizen {
+	c := Citizen{
+		Person: randomUser(r),
+		Height: randomFloat32(r),
+		Admin:  r.Intn(2) == 0,
+		Extra:  randomInterface(r, depth),
+		Ratio:  randomFloat(r),
+		Code:   int8(r.Intn(256) - 128),
+		Big:    r.Uint64(),
+	}
+	if r.Intn(3) > 0 {
+		c.Tags = make([]string, r.Intn(4))
+		for i := range c.Tags {
+			c.Tags[i] = randomString(r)
+		}
+	}
+	if r.Intn(3) > 0 {
+		c.Scores = make(map[string]int)
+		for i := r.Intn(5); i > 0; i-- {
+			c.Scores[randomString(r)] = r.Int() - r.Int()
+		}
+	}
+	if r.Intn(2) == 0 {
+		c.Home = &Address{Street: randomString(r), Zip: uint16(r.Intn(1 << 16)), Geo: [2]float64{randomFloat(r), randomFloat(r)}}
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		c.Friends = append(c.Friends, randomUser(r))
+	}
+	if r.Intn(2) == 0 {
+		c.Cities = make(map[int64]City)
+		for i := r.Intn(4); i > 0; i-- {
+			c.Cities[r.Int63n(2000)-1000] = randomCity(r)
+		}
+	}
+	return c
+}
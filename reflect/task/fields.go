@@ -0,0 +1,263 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// field is a struct field as encoding/json sees it, possibly promoted from
+// an embedded struct. The other formats resolve fields the same way with
+// their own tag key.
+type field struct {
+	name string
+	// tag reports whether the name comes from the tag.
+	tag bool
+	// index is the path to the field through embedded structs.
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	// quoted is the ",string" option, it applies to strings, numbers and bools.
+	quoted bool
+	// opts are all the tag options, for the options specific to a format
+	opts tagOptions
+}
+
+type fieldsKey struct {
+	t   reflect.Type
+	key string
+}
+
+// fieldCache is a map[fieldsKey][]field.
+var fieldCache sync.Map
+
+// cachedTypeFields is typeFields computed once per type and tag key.
+func cachedTypeFields(t reflect.Type, key string) []field {
+	if f, ok := fieldCache.Load(fieldsKey{t, key}); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(fieldsKey{t, key}, typeFields(t, key))
+	return f.([]field)
+}
+
+// typeFields returns the fields encoding/json encodes for t, named by the
+// tags with the given key, "json" for JSON, in the order
+// of their index paths. Like encoding/json it walks the embedded structs
+// breadth first, so a field hides the fields with the same name deeper
+// down, and fields with the same name at the same depth hide each other
+// unless exactly one of them is tagged.
+func typeFields(t reflect.Type, key string) []field {
+	current := []field{}
+	next := []field{{typ: t}}
+
+	// the number of times a struct type is embedded at the current and next depth
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+
+	var fields []field
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					t := sf.Type
+					if t.Kind() == reflect.Ptr {
+						t = t.Elem()
+					}
+					// embedded unexported structs may still have exported fields
+					if sf.PkgPath != "" && t.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+
+				tag := sf.Tag.Get(key)
+				if tag == "-" {
+					continue
+				}
+				name, opts := parseTag(tag)
+				if !isValidTag(name) {
+					name = ""
+				}
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				quoted := false
+				if key == "json" && opts.contains("string") {
+					switch ft.Kind() {
+					case reflect.Bool,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64,
+						reflect.String:
+						quoted = true
+					}
+				}
+
+				// a tagged embedded struct is a regular field
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					tagged := name != ""
+					if name == "" {
+						name = sf.Name
+					}
+					fields = append(fields, field{
+						name:      name,
+						tag:       tagged,
+						index:     index,
+						typ:       ft,
+						omitEmpty: opts.contains("omitempty"),
+						quoted:    quoted,
+						opts:      opts,
+					})
+					if count[f.typ] > 1 {
+						// the struct is embedded twice at this depth, the copy
+						// makes the duplicate fields hide each other
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, field{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+		if x[i].tag != x[j].tag {
+			return x[i].tag
+		}
+		return byIndex(x[i].index, x[j].index)
+	})
+
+	// keep the dominant field of each name
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		name := fields[i].name
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fields[i])
+			continue
+		}
+		if dominant, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return byIndex(out[i].index, out[j].index)
+	})
+	return out
+}
+
+// dominantField returns the field hiding the others with its name. The fields
+// are sorted by depth and tagged first, so it's the first unless the second
+// is just as deep and tagged.
+func dominantField(fields []field) (field, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tag == fields[1].tag {
+		return field{}, false
+	}
+	return fields[0], true
+}
+
+func byIndex(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+type tagOptions string
+
+func parseTag(tag string) (string, tagOptions) {
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], tagOptions(tag[i+1:])
+	}
+	return tag, ""
+}
+
+func (o tagOptions) contains(name string) bool {
+	s := string(o)
+	for s != "" {
+		var opt string
+		if i := strings.IndexByte(s, ','); i >= 0 {
+			opt, s = s[:i], s[i+1:]
+		} else {
+			opt, s = s, ""
+		}
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidTag reports whether the tag name can be used as a key, quotes and
+// backslashes are reserved.
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// isEmptyValue is the omitempty emptiness: false, 0, a nil pointer or
+// interface, and an empty array, slice, map or string.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
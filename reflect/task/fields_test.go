@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type Tagged struct {
+	Name     string  `json:"name"`
+	Age      int64   `json:"age,omitempty"`
+	Skip     string  `json:"-"`
+	Dash     string  `json:"-,"`
+	Default  bool    `json:",omitempty"`
+	Punct    int     `json:"$x-y.z"`
+	Quoted   int     `json:"quoted,string"`
+	QStr     string  `json:",string"`
+	QFloat   float64 `json:",string"`
+	QBool    bool    `json:",string"`
+	QPtr     *int    `json:",string"`
+	QNilPtr  *int    `json:",string,omitempty"`
+	QSlice   []int   `json:",string"`
+	Options  string  `json:"opts,omitempty,string"`
+	Unknown  int     `json:",whatever"`
+	internal int
+}
+
+type Empty struct {
+	S   string                 `json:",omitempty"`
+	I   int                    `json:",omitempty"`
+	U   uint8                  `json:",omitempty"`
+	F   float32                `json:",omitempty"`
+	B   bool                   `json:",omitempty"`
+	P   *int                   `json:",omitempty"`
+	E   interface{}            `json:",omitempty"`
+	Sl  []int                  `json:",omitempty"`
+	M   map[string]int         `json:",omitempty"`
+	A   [0]int                 `json:",omitempty"`
+	A1  [1]int                 `json:",omitempty"`
+	St  struct{}               `json:",omitempty"`
+	EMp map[string]interface{} `json:",omitempty"`
+}
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type Audit struct {
+	Created string
+	ID      string `json:"id"`
+}
+
+type Named struct{ Base }
+
+type hidden struct {
+	Visible string
+	secret  string
+}
+
+type myInt int
+
+type Level1 struct{ X, Y int }
+
+type Level2 struct{ Level1 }
+
+type Conflict1 struct{ Dup string }
+
+type Conflict2 struct{ Dup string }
+
+type Tagged1 struct {
+	Dup string `json:"Dup"`
+}
+
+type Promoted struct {
+	Base
+	*Audit
+	hidden
+	myInt
+	Named `json:"named"`
+	Name  string
+}
+
+type Depth struct {
+	Level2
+	X string
+}
+
+type Ambiguous struct {
+	Conflict1
+	Conflict2
+	Other int
+}
+
+type TagWins struct {
+	Conflict1
+	Tagged1
+}
+
+type Twice struct {
+	Level1
+	Level2
+}
+
+func TestTags(t *testing.T) {
+	seven := 7
+	for _, v := range []interface{}{
+		Tagged{},
+		Tagged{Name: "bob", Age: 10, Skip: "x", Dash: "d", Default: true, Punct: 2, Quoted: 3,
+			QStr: `<"q">`, QFloat: 1e-7, QBool: true, QPtr: &seven, QNilPtr: &seven, QSlice: []int{1}, Options: "o", internal: 4},
+		Empty{},
+		Empty{S: "s", I: -1, U: 1, F: 0.5, B: true, P: new(int), E: 0, Sl: []int{}, M: map[string]int{}, EMp: map[string]interface{}{"a": nil}},
+		Promoted{},
+		Promoted{Base: Base{1, "base"}, Audit: &Audit{"today", "audit"}, hidden: hidden{"v", "s"}, myInt: 5,
+			Named: Named{Base{2, "named"}}, Name: "outer"},
+		&Promoted{Audit: nil},
+		Depth{Level2{Level1{1, 2}}, "x"},
+		Ambiguous{Conflict1{"a"}, Conflict2{"b"}, 3},
+		TagWins{Conflict1{"untagged"}, Tagged1{"tagged"}},
+		Twice{Level1{1, 2}, Level2{Level1{3, 4}}},
+	} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := JSONEncode(v)
+		if err != nil {
+			t.Errorf("%#v: %v", v, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%T:\nexpected %s\ngot      %s", v, want, got)
+		}
+	}
+}
+
+func TestTypeFields(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want []string
+	}{
+		{Tagged{}, []string{"name", "age", "-", "Default", "$x-y.z", "quoted", "QStr", "QFloat", "QBool", "QPtr", "QNilPtr", "QSlice", "opts", "Unknown"}},
+		{Promoted{}, []string{"ID", "Created", "id", "Visible", "named", "Name"}},
+		{Depth{}, []string{"Y", "X"}},
+		{Ambiguous{}, []string{"Other"}},
+		{TagWins{}, []string{"Dup"}},
+		{Twice{}, []string{"X", "Y"}},
+	} {
+		var got []string
+		for _, f := range typeFields(typeOf(tt.v), "json") {
+			got = append(got, f.name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%T: expected %v, got %v", tt.v, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%T: expected %v, got %v", tt.v, tt.want, got)
+				break
+			}
+		}
+	}
+}
+
+func typeOf(v interface{}) reflect.Type {
+	return reflect.TypeOf(v)
+}
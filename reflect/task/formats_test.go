@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// Note has the tags of every format.
+type Note struct {
+	ID      int               `xml:"id,attr" yaml:"id" url:"id"`
+	Lang    string            `xml:"lang,attr,omitempty" yaml:"lang,omitempty" url:"lang,omitempty"`
+	Text    string            `xml:",chardata" yaml:"text" url:"text"`
+	Author  *User             `xml:"author" yaml:"author" url:"author"`
+	Tags    []string          `xml:"tag" yaml:"tags" url:"tag"`
+	Colors  map[Color]float64 `xml:"colors" yaml:"colors,omitempty" url:"colors"`
+	Created time.Time         `xml:"created" yaml:"created" url:"created"`
+	Blob    []byte            `xml:"blob" yaml:"blob" url:"-"`
+	Secret  string            `xml:"-" yaml:"-" url:"-"`
+	// Label is nil in the golden files
+	Label encoding.TextMarshaler `xml:"label" yaml:"label" url:"label"`
+}
+
+func goldenValues() map[string]interface{} {
+	return map[string]interface{}{
+		"user": User{"bob", 10},
+		"city": City{"sf", 5000000, 567896, ""},
+		"note": Note{
+			ID:      7,
+			Text:    "a <b> & \"c\"",
+			Author:  &User{"ann", 30},
+			Tags:    []string{"go", "true", "12", "- x", "a: b", ""},
+			Colors:  map[Color]float64{2: 0.5, 0: 1e21},
+			Created: time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC),
+			Blob:    []byte{0, 1, 2, 250},
+			Secret:  "hidden",
+		},
+		"citizen": Citizen{
+			Person:  User{"carl", 40},
+			Height:  1.8,
+			Tags:    []string{"multi\nline", "é世😀"},
+			Scores:  map[string]int{"b": -2, "a b": 1},
+			Home:    &Address{Street: "Main st", Zip: 12345, Geo: [2]float64{1.5, -2}},
+			Extra:   []interface{}{map[string]interface{}{"k": nil}, []interface{}{}, []int{1, 2}},
+			Friends: []User{{"dan", 1}, {"eve", 2}},
+			Cities:  map[int64]City{-1: {Name: "x", Mayor: "m"}},
+			Big:     1 << 63,
+		},
+	}
+}
+
+func TestFormatsGolden(t *testing.T) {
+	formats := map[string]func(interface{}) ([]byte, error){
+		"xml": func(v interface{}) ([]byte, error) {
+			b, err := XMLEncode(v)
+			return append(b, '\n'), err
+		},
+		"yaml": YAMLEncode,
+		"query": func(v interface{}) ([]byte, error) {
+			q, err := QueryEncode(v)
+			return []byte(q + "\n"), err
+		},
+	}
+
+	for name, v := range goldenValues() {
+		for ext, encode := range formats {
+			got, err := encode(v)
+			if err != nil {
+				t.Errorf("%s.%s: %v", name, ext, err)
+				continue
+			}
+			path := filepath.Join("testdata", name+"."+ext)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("%s:\nexpected %s\ngot      %s", path, want, got)
+			}
+		}
+	}
+}
+
+func TestFormatsErrors(t *testing.T) {
+	loop := &Loop{Name: "a"}
+	loop.Next = loop
+
+	for _, tt := range []struct {
+		encode func(interface{}) error
+		v      interface{}
+		want   string
+	}{
+		{xmlEncode, loop, "xml: unsupported value: encountered a cycle via *main.Loop"},
+		{yamlEncode, map[string]interface{}{"c": make(chan int)}, "yaml: unsupported type: chan int"},
+		{yamlEncode, []Failing{{}}, "yaml: error calling MarshalText for type main.Failing: no text"},
+		{queryEncode, []int{1}, "url: QueryEncode of []int, not a struct or a map"},
+		{xmlEncode, struct {
+			A []int `xml:"a,attr"`
+		}{[]int{1}}, "xml: attribute a of value isn't a scalar"},
+	} {
+		if err := tt.encode(tt.v); err == nil || err.Error() != tt.want {
+			t.Errorf("%T: expected %q, got %v", tt.v, tt.want, err)
+		}
+	}
+}
+
+func xmlEncode(v interface{}) error {
+	_, err := XMLEncode(v)
+	return err
+}
+
+func yamlEncode(v interface{}) error {
+	_, err := YAMLEncode(v)
+	return err
+}
+
+func queryEncode(v interface{}) error {
+	_, err := QueryEncode(v)
+	return err
+}
+
+func TestYAMLStrings(t *testing.T) {
+	for s, quoted := range map[string]bool{
+		"plain":      false,
+		"two words":  false,
+		"":           true,
+		"null":       true,
+		"Yes":        true,
+		"1.5":        true,
+		"-x":         true,
+		"a: b":       true,
+		"a #b":       true,
+		" lead":      true,
+		"tab\there":  true,
+		"line\nfeed": true,
+		"日本":         false,
+	} {
+		if got := strings.HasPrefix(yamlString(s), `"`); got != quoted {
+			t.Errorf("%q: expected quoted %v, got %s", s, quoted, yamlString(s))
+		}
+	}
+}
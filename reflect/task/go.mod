@@ -0,0 +1,3 @@
+module github.com/awnzl/workshops/reflect/task
+
+go 1.16
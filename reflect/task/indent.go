@@ -0,0 +1,98 @@
+package main
+
+import "bytes"
+
+// compact writes the valid JSON src to dst without the insignificant
+// spaces, escaping HTML characters in strings like writeString does.
+func compact(dst *bytes.Buffer, src []byte) {
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			switch {
+			case c == '<' || c == '>' || c == '&':
+				dst.WriteString(`\u00`)
+				dst.WriteByte(hex[c>>4])
+				dst.WriteByte(hex[c&0xF])
+				continue
+			// U+2028 and U+2029 are E2 80 A8 and E2 80 A9
+			case c == 0xE2 && i+2 < len(src) && src[i+1] == 0x80 && src[i+2]&^1 == 0xA8:
+				dst.WriteString(`\u202`)
+				dst.WriteByte(hex[src[i+2]&0xF])
+				i += 2
+				continue
+			case c == '\\':
+				dst.WriteByte(c)
+				i++
+				c = src[i]
+			case c == '"':
+				inString = false
+			}
+			dst.WriteByte(c)
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '"':
+			inString = true
+		}
+		dst.WriteByte(c)
+	}
+}
+
+// indent writes the compact JSON src to dst, with every element of objects
+// and arrays on a new line starting with prefix and depth times indent.
+// Empty objects and arrays stay on one line.
+func indent(dst *bytes.Buffer, src []byte, prefix, indent string) {
+	newline := func(depth int) {
+		dst.WriteByte('\n')
+		dst.WriteString(prefix)
+		for i := 0; i < depth; i++ {
+			dst.WriteString(indent)
+		}
+	}
+
+	depth := 0
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			dst.WriteByte(c)
+			switch c {
+			case '\\':
+				i++
+				dst.WriteByte(src[i])
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			dst.WriteByte(c)
+		case '{', '[':
+			dst.WriteByte(c)
+			if i+1 < len(src) && (src[i+1] == '}' || src[i+1] == ']') {
+				dst.WriteByte(src[i+1])
+				i++
+				continue
+			}
+			depth++
+			newline(depth)
+		case '}', ']':
+			depth--
+			newline(depth)
+			dst.WriteByte(c)
+		case ',':
+			dst.WriteByte(c)
+			newline(depth)
+		case ':':
+			dst.WriteString(": ")
+		default:
+			dst.WriteByte(c)
+		}
+	}
+}
@@ -1,20 +1,19 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 )
 
 type User struct {
-	Name string
-	Age  int64
+	Name string `xml:"name" yaml:"name" url:"name"`
+	Age  int64  `xml:"age,attr" yaml:"age" url:"age"`
 }
 
 type City struct {
-	Name       string
-	Population int64
-	GDP        int64
-	Mayor      string
+	Name       string `xml:"name,attr" yaml:"name" url:"name"`
+	Population int64  `xml:"population" yaml:"population" url:"population"`
+	GDP        int64  `xml:"gdp" yaml:"gdp" url:"gdp"`
+	Mayor      string `xml:"mayor,omitempty" yaml:"mayor,omitempty" url:"mayor,omitempty"`
 }
 
 func main() {
@@ -32,17 +31,28 @@ func main() {
 		panic(err)
 	}
 	fmt.Println(string(res))
-}
 
-func JSONEncode(v interface{}) ([]byte, error) {
-	buf := bytes.Buffer{}
-	buf.WriteString("{")
+	var decoded City
+	if err := JSONDecode(res, &decoded); err != nil {
+		panic(err)
+	}
+	fmt.Printf("%+v\n", decoded)
+
+	res, err = XMLEncode(u)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(res))
 
-	// TODO: check if v is a struct else return error
-	// TODO: iterate over v`s reflect value using NumField()
-	// use type switch to create string result of "{field}" + ": " + "{value}"
-	// start with just 2 types - reflect.String and reflect.Int64
+	res, err = YAMLEncode(u)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(res))
 
-	buf.WriteString("}")
-	return buf.Bytes(), nil
+	query, err := QueryEncode(u)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(query)
 }
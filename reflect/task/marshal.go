@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Marshaler is implemented by types encoding themselves to JSON,
+// it's the same interface as encoding/json.Marshaler.
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// MarshalerError is returned by JSONEncode when a MarshalJSON or
+// MarshalText method fails or returns invalid JSON.
+type MarshalerError struct {
+	Type       reflect.Type
+	Err        error
+	sourceFunc string
+}
+
+func (e *MarshalerError) Error() string {
+	return "json: error calling " + e.sourceFunc + " for type " + e.Type.String() + ": " + e.Err.Error()
+}
+
+func (e *MarshalerError) Unwrap() error { return e.Err }
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// hasMarshaler tells whether the values of t, or their addresses, encode themselves.
+func hasMarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(marshalerType) || pt.Implements(marshalerType) ||
+		t.Implements(textMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+func marshalerEncoder(e *encodeState, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+	m, ok := v.Interface().(Marshaler)
+	if !ok {
+		// a nil interface
+		e.WriteString("null")
+		return nil
+	}
+	b, err := m.MarshalJSON()
+	if err == nil {
+		err = validJSON(b)
+	}
+	if err != nil {
+		return &MarshalerError{Type: v.Type(), Err: err, sourceFunc: "MarshalJSON"}
+	}
+	compact(&e.Buffer, b)
+	return nil
+}
+
+func addrMarshalerEncoder(e *encodeState, v reflect.Value) error {
+	return marshalerEncoder(e, v.Addr())
+}
+
+func textMarshalerEncoder(e *encodeState, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+	m, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok {
+		e.WriteString("null")
+		return nil
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return &MarshalerError{Type: v.Type(), Err: err, sourceFunc: "MarshalText"}
+	}
+	writeString(&e.Buffer, string(b))
+	return nil
+}
+
+func addrTextMarshalerEncoder(e *encodeState, v reflect.Value) error {
+	return textMarshalerEncoder(e, v.Addr())
+}
+
+// newCondAddrEncoder encodes addressable values with addrEnc and the others with enc.
+func newCondAddrEncoder(addrEnc, enc encoderFunc) encoderFunc {
+	return func(e *encodeState, v reflect.Value) error {
+		if v.CanAddr() {
+			return addrEnc(e, v)
+		}
+		return enc(e, v)
+	}
+}
+
+// timeEncoder writes a time.Time as its MarshalJSON does, without
+// the allocations of the call.
+func timeEncoder(e *encodeState, v reflect.Value) error {
+	var t time.Time
+	if v.CanAddr() {
+		t = *v.Addr().Interface().(*time.Time)
+	} else {
+		t = v.Interface().(time.Time)
+	}
+	if y := t.Year(); y < 0 || y >= 10000 {
+		return &MarshalerError{Type: timeType, Err: errors.New("Time.MarshalJSON: year outside of range [0,9999]"), sourceFunc: "MarshalJSON"}
+	}
+	b := append(e.scratch[:0], '"')
+	b = t.AppendFormat(b, time.RFC3339Nano)
+	e.Write(append(b, '"'))
+	return nil
+}
+
+func bytesEncoder(e *encodeState, v reflect.Value) error {
+	if v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+	b := v.Bytes()
+	e.WriteByte('"')
+	if n := base64.StdEncoding.EncodedLen(len(b)); n <= len(e.scratch) {
+		base64.StdEncoding.Encode(e.scratch[:n], b)
+		e.Write(e.scratch[:n])
+	} else {
+		enc := base64.NewEncoder(base64.StdEncoding, &e.Buffer)
+		enc.Write(b)
+		enc.Close()
+	}
+	e.WriteByte('"')
+	return nil
+}
+
+// validJSON checks the output of a MarshalJSON method.
+func validJSON(b []byte) error {
+	d := &decoder{data: b}
+	d.skipSpace()
+	if err := d.skip(); err != nil {
+		return err
+	}
+	d.skipSpace()
+	if d.pos < len(d.data) {
+		return d.syntaxError("after top-level value")
+	}
+	return nil
+}
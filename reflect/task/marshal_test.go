@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Celsius encodes itself with a value receiver.
+type Celsius float64
+
+func (c Celsius) MarshalJSON() ([]byte, error) {
+	// spaces and HTML characters, JSONEncode compacts and escapes them
+	return []byte(` { "celsius" : ` + strconv.FormatFloat(float64(c), 'f', -1, 64) + `, "note": "<hot & cold>\u00e9" } `), nil
+}
+
+func (c *Celsius) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var v struct{ Celsius float64 }
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*c = Celsius(v.Celsius)
+	return nil
+}
+
+// Point encodes itself with a pointer receiver, so only when addressable.
+type Point struct{ X, Y int }
+
+func (p *Point) MarshalJSON() ([]byte, error) {
+	return []byte(`[` + string(rune('0'+p.X)) + `,` + string(rune('0'+p.Y)) + `]`), nil
+}
+
+func (p *Point) UnmarshalJSON(b []byte) error {
+	var xy [2]int
+	if err := json.Unmarshal(b, &xy); err != nil {
+		return err
+	}
+	p.X, p.Y = xy[0], xy[1]
+	return nil
+}
+
+// Color is a text marshaler, also used as a map key.
+type Color int
+
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte([]string{"red", "green", "blue"}[c]), nil
+}
+
+func (c *Color) UnmarshalText(b []byte) error {
+	for i, name := range []string{"red", "green", "blue"} {
+		if string(b) == name {
+			*c = Color(i)
+			return nil
+		}
+	}
+	return errors.New("unknown color " + string(b))
+}
+
+// Upper is a string text marshaler: as a map key it's used as is.
+type Upper string
+
+func (u Upper) MarshalText() ([]byte, error) { return []byte(strings.ToUpper(string(u))), nil }
+
+type Broken struct{}
+
+func (Broken) MarshalJSON() ([]byte, error) { return []byte(`{"a":}`), nil }
+
+type Failing struct{}
+
+func (Failing) MarshalText() ([]byte, error) { return nil, errors.New("no text") }
+
+type Hooks struct {
+	Temp    Celsius
+	TempPtr *Celsius
+	Point   Point
+	Points  []Point
+	Color   Color
+	Colors  map[Color]int
+	Uppers  map[Upper]int
+	When    time.Time
+	WhenPtr *time.Time
+	Data    []byte
+	Big     []byte
+	Fixed   [3]byte
+	Nil     []byte
+	Count   Celsius `json:",string"`
+	Any     interface{}
+}
+
+func TestJSONEncodeMarshalers(t *testing.T) {
+	when := time.Date(2021, 6, 1, 12, 30, 0, 123456789, time.FixedZone("X", 3*3600))
+	temp := Celsius(21.5)
+	hooks := Hooks{
+		Temp:    36.6,
+		TempPtr: &temp,
+		Point:   Point{1, 2},
+		Points:  []Point{{3, 4}},
+		Color:   2,
+		Colors:  map[Color]int{0: 1, 1: 2},
+		Uppers:  map[Upper]int{"b": 1, "a": 2},
+		When:    when,
+		WhenPtr: &when,
+		Data:    []byte("hello, world"),
+		Big:     []byte(strings.Repeat("0123456789", 20)),
+		Fixed:   [3]byte{1, 2, 3},
+		Count:   3,
+		Any:     Color(1),
+	}
+
+	for _, v := range []interface{}{
+		hooks,
+		&hooks,
+		[]Hooks{hooks},
+		when,
+		(*Celsius)(nil),
+		map[string]Point{"p": {5, 6}},
+		map[string]Hooks{"h": hooks},
+	} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := JSONEncode(v)
+		if err != nil {
+			t.Errorf("%#v: %v", v, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%#v:\nexpected %s\ngot      %s", v, want, got)
+		}
+	}
+}
+
+type Loop struct {
+	Name string
+	Next *Loop
+}
+
+func TestJSONEncodeHookErrors(t *testing.T) {
+	loop := &Loop{Name: "a"}
+	loop.Next = &Loop{Name: "b", Next: loop}
+	cyclicMap := map[string]interface{}{}
+	cyclicMap["self"] = cyclicMap
+	cyclicSlice := []interface{}{nil}
+	cyclicSlice[0] = cyclicSlice
+
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{loop, "json: unsupported value: encountered a cycle via *main.Loop"},
+		{cyclicMap, "json: unsupported value: encountered a cycle via map[string]interface {}"},
+		{cyclicSlice, "json: unsupported value: encountered a cycle via []interface {}"},
+		{Broken{}, "json: error calling MarshalJSON for type main.Broken: json: invalid character '}' looking for beginning of value at offset 5 (a)"},
+		{Failing{}, "json: error calling MarshalText for type main.Failing: no text"},
+		{map[Failing]int{{}: 1}, "json: error calling MarshalText for type main.Failing: no text"},
+		{time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC), "json: error calling MarshalJSON for type time.Time: Time.MarshalJSON: year outside of range [0,9999]"},
+	} {
+		if _, err := JSONEncode(tt.v); err == nil || err.Error() != tt.want {
+			t.Errorf("%T: expected %q, got %v", tt.v, tt.want, err)
+		}
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+	v := []interface{}{
+		Citizen{Person: User{"c", 1}, Tags: []string{}, Scores: map[string]int{"b": 2, "a": 1}, Home: &Address{Street: `"{[,:]}"`}},
+		map[string]interface{}{},
+		"x",
+	}
+	want, err := json.MarshalIndent(v, "> ", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := EncodeOptions{Prefix: "> ", Indent: "\t"}.Encode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("indented:\nexpected %s\ngot      %s", want, got)
+	}
+
+	m := map[int]string{}
+	for i := 0; i < 100; i++ {
+		m[i] = strings.Repeat("x", i%3)
+	}
+	got, err = EncodeOptions{UnsortedKeys: true}.Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back map[int]string
+	if err := JSONDecode(got, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != len(m) {
+		t.Errorf("unsorted: expected %d entries, got %d", len(m), len(back))
+	}
+	for k, v := range m {
+		if back[k] != v {
+			t.Errorf("unsorted: expected %d: %q, got %q", k, v, back[k])
+		}
+	}
+}
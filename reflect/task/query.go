@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// QueryEncode returns v, a struct or a map, as a URL query string like
+// "name=bob&age=10", with the fields named by the "url" tag. Lists repeat
+// their key, nested objects are keyed "parent[child]" and the objects in
+// lists "parent[i][child]". Null values are left out.
+func QueryEncode(v interface{}) (string, error) {
+	n, err := walk(v, "url")
+	if err != nil {
+		return "", err
+	}
+	switch n.kind {
+	case nullNode:
+		return "", nil
+	case objectNode:
+	default:
+		return "", fmt.Errorf("url: QueryEncode of %s, not a struct or a map", reflect.TypeOf(v))
+	}
+
+	var params []string
+	writeQuery(&params, "", n)
+	return strings.Join(params, "&"), nil
+}
+
+func writeQuery(params *[]string, key string, n *node) {
+	switch n.kind {
+	case scalarNode:
+		*params = append(*params, url.QueryEscape(key)+"="+url.QueryEscape(n.text))
+	case objectNode:
+		for _, f := range n.fields {
+			writeQuery(params, queryKey(key, f.name), f.value)
+		}
+	case listNode:
+		for i, item := range n.items {
+			if item.kind == scalarNode {
+				writeQuery(params, key, item)
+			} else {
+				writeQuery(params, queryKey(key, strconv.Itoa(i)), item)
+			}
+		}
+	}
+}
+
+func queryKey(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "[" + name + "]"
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding"
+	"reflect"
+)
+
+// Unmarshaler is implemented by types decoding themselves from JSON,
+// it's the same interface as encoding/json.Unmarshaler.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// indirect allocates nil pointers on the way to a non-pointer value, it
+// stops at a pointer when decoding null, which sets the pointer to nil.
+// A value decoding itself stops the way as well, its Unmarshaler or
+// TextUnmarshaler is returned instead; null isn't text, so it isn't passed
+// to UnmarshalText.
+func indirect(v reflect.Value, null bool) (Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
+	for {
+		if v.Kind() != reflect.Ptr {
+			if v.CanAddr() {
+				if u, tu := unmarshalers(v.Addr(), null); u != nil || tu != nil {
+					return u, tu, reflect.Value{}
+				}
+			}
+			return nil, nil, v
+		}
+		if null && v.CanSet() {
+			return nil, nil, v
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if u, tu := unmarshalers(v, null); u != nil || tu != nil {
+			return u, tu, reflect.Value{}
+		}
+		v = v.Elem()
+	}
+}
+
+// unmarshalers returns the methods p, a pointer, decodes itself with.
+func unmarshalers(p reflect.Value, null bool) (Unmarshaler, encoding.TextUnmarshaler) {
+	if p.Type().NumMethod() == 0 || !p.CanInterface() {
+		return nil, nil
+	}
+	if u, ok := p.Interface().(Unmarshaler); ok {
+		return u, nil
+	}
+	if tu, ok := p.Interface().(encoding.TextUnmarshaler); ok && !null {
+		return nil, tu
+	}
+	return nil, nil
+}
+
+// tokenName names the JSON value starting with c in type errors.
+func tokenName(c byte) string {
+	switch c {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	default:
+		return "number"
+	}
+}
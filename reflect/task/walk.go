@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The formats other than JSON share one walk over the value, reducing it to
+// a tree of nodes with the struct fields resolved by their tag key, and
+// render the tree. JSONEncode has the same rules but compiles its encoders,
+// it's the one on the hot path.
+
+type nodeKind int
+
+const (
+	nullNode nodeKind = iota
+	// scalarNode is a string, number or bool as text
+	scalarNode
+	// objectNode is a struct or a map, with named fields
+	objectNode
+	// listNode is a slice or an array
+	listNode
+)
+
+// scalarKind tells the formats which scalars may need quoting.
+type scalarKind int
+
+const (
+	stringScalar scalarKind = iota
+	numberScalar
+	boolScalar
+)
+
+type node struct {
+	kind   nodeKind
+	scalar scalarKind
+	text   string
+	fields []nodeField
+	// entries tells the fields of an object are map entries
+	entries bool
+	items   []*node
+}
+
+type nodeField struct {
+	name string
+	// opts are the field's tag options, empty for map entries
+	opts  tagOptions
+	value *node
+}
+
+// walker builds the node tree of a value for the format named key, which is
+// also its tag key and the prefix of its errors.
+type walker struct {
+	key string
+	// seen are the pointers, maps and slices on the way, to detect cycles
+	seen map[ptrKey]bool
+}
+
+func walk(v interface{}, key string) (*node, error) {
+	w := &walker{key: key, seen: map[ptrKey]bool{}}
+	return w.walk(reflect.ValueOf(v))
+}
+
+func (w *walker) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(w.key+": "+format, args...)
+}
+
+func (w *walker) walk(v reflect.Value) (*node, error) {
+	if !v.IsValid() {
+		return &node{kind: nullNode}, nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == timeType:
+		return &node{kind: scalarNode, text: v.Interface().(time.Time).Format(time.RFC3339Nano)}, nil
+	case t.Implements(textMarshalerType):
+		if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) && v.IsNil() {
+			return &node{kind: nullNode}, nil
+		}
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, w.errorf("error calling MarshalText for type %s: %w", t, err)
+		}
+		return &node{kind: scalarNode, text: string(b)}, nil
+	case v.CanAddr() && reflect.PtrTo(t).Implements(textMarshalerType):
+		return w.walk(v.Addr())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return &node{kind: scalarNode, text: v.String()}, nil
+	case reflect.Bool:
+		return &node{kind: scalarNode, scalar: boolScalar, text: strconv.FormatBool(v.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &node{kind: scalarNode, scalar: numberScalar, text: strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &node{kind: scalarNode, scalar: numberScalar, text: strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, w.errorf("unsupported value: %v", f)
+		}
+		return &node{kind: scalarNode, scalar: numberScalar, text: string(appendFloat(nil, f, t.Bits()))}, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return &node{kind: nullNode}, nil
+		}
+		return w.walk(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return &node{kind: nullNode}, nil
+		}
+		return w.enter(v, 0, func() (*node, error) { return w.walk(v.Elem()) })
+	case reflect.Struct:
+		return w.object(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return &node{kind: nullNode}, nil
+		}
+		return w.enter(v, 0, func() (*node, error) { return w.mapObject(v) })
+	case reflect.Slice:
+		if v.IsNil() {
+			return &node{kind: nullNode}, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 && !hasMarshaler(t.Elem()) {
+			return &node{kind: scalarNode, text: base64.StdEncoding.EncodeToString(v.Bytes())}, nil
+		}
+		return w.enter(v, v.Len(), func() (*node, error) { return w.list(v) })
+	case reflect.Array:
+		return w.list(v)
+	default:
+		return nil, w.errorf("unsupported type: %s", t)
+	}
+}
+
+// enter walks into the target of a pointer, a map or a slice with f,
+// unless it's already on the way.
+func (w *walker) enter(v reflect.Value, len int, f func() (*node, error)) (*node, error) {
+	k := ptrKey{v.Pointer(), len}
+	if w.seen[k] {
+		return nil, w.errorf("unsupported value: encountered a cycle via %s", v.Type())
+	}
+	w.seen[k] = true
+	defer delete(w.seen, k)
+	return f()
+}
+
+func (w *walker) object(v reflect.Value) (*node, error) {
+	n := &node{kind: objectNode}
+	for _, f := range cachedTypeFields(v.Type(), w.key) {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		value, err := w.walk(fv)
+		if err != nil {
+			return nil, err
+		}
+		n.fields = append(n.fields, nodeField{name: f.name, opts: f.opts, value: value})
+	}
+	return n, nil
+}
+
+func (w *walker) mapObject(v reflect.Value) (*node, error) {
+	text := v.Type().Key().Implements(textMarshalerType)
+	switch v.Type().Key().Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		if !text {
+			return nil, w.errorf("unsupported type: %s", v.Type().Key())
+		}
+	}
+
+	n := &node{kind: objectNode, entries: true}
+	iter := v.MapRange()
+	for iter.Next() {
+		var name string
+		if text {
+			key, err := w.walk(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			name = key.text
+		} else {
+			name, _ = mapKey(iter.Key(), false)
+		}
+		value, err := w.walk(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		n.fields = append(n.fields, nodeField{name: name, value: value})
+	}
+	sort.Slice(n.fields, func(i, j int) bool { return n.fields[i].name < n.fields[j].name })
+	return n, nil
+}
+
+func (w *walker) list(v reflect.Value) (*node, error) {
+	n := &node{kind: listNode, items: make([]*node, v.Len())}
+	for i := range n.items {
+		item, err := w.walk(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		n.items[i] = item
+	}
+	return n, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+)
+
+// XMLEncode returns the XML of v: an element named after the type of v,
+// with a child element for each field named by the "xml" tag. Fields tagged
+// ",attr" are attributes and ",chardata" the text of the element, slices
+// repeat the element of their field, and map entries are <entry key="...">.
+// Null values are left out.
+func XMLEncode(v interface{}) ([]byte, error) {
+	n, err := walk(v, "xml")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeXML(&buf, xmlRootName(reflect.TypeOf(v)), "", n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlRootName is the name of the type, of the elements' type for lists.
+func xmlRootName(t reflect.Type) string {
+	for t != nil {
+		k := t.Kind()
+		if k != reflect.Ptr && (k != reflect.Slice && k != reflect.Array || t.Elem().Kind() == reflect.Uint8) {
+			break
+		}
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "value"
+	}
+	return t.Name()
+}
+
+// writeXML writes n as elements called name, extra are attributes
+// written as is in their start tags.
+func writeXML(buf *bytes.Buffer, name, extra string, n *node) error {
+	switch n.kind {
+	case nullNode:
+		return nil
+	case listNode:
+		for _, item := range n.items {
+			if err := writeXML(buf, name, extra, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	buf.WriteByte('<')
+	buf.WriteString(name)
+	buf.WriteString(extra)
+	if n.kind == scalarNode {
+		buf.WriteByte('>')
+		xml.EscapeText(buf, []byte(n.text))
+		writeXMLEnd(buf, name)
+		return nil
+	}
+
+	for _, f := range n.fields {
+		if !f.opts.contains("attr") || f.value.kind == nullNode {
+			continue
+		}
+		if f.value.kind != scalarNode {
+			return fmt.Errorf("xml: attribute %s of %s isn't a scalar", f.name, name)
+		}
+		buf.WriteString(xmlAttr(f.name, f.value.text))
+	}
+	buf.WriteByte('>')
+
+	for _, f := range n.fields {
+		var err error
+		switch {
+		case n.entries:
+			// the keys aren't always valid names
+			err = writeXML(buf, "entry", xmlAttr("key", f.name), f.value)
+		case f.opts.contains("attr"):
+		case f.opts.contains("chardata"):
+			if f.value.kind == scalarNode {
+				xml.EscapeText(buf, []byte(f.value.text))
+			}
+		default:
+			err = writeXML(buf, f.name, "", f.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	writeXMLEnd(buf, name)
+	return nil
+}
+
+func xmlAttr(name, value string) string {
+	var buf bytes.Buffer
+	buf.WriteByte(' ')
+	buf.WriteString(name)
+	buf.WriteString(`="`)
+	xml.EscapeText(&buf, []byte(value))
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+func writeXMLEnd(buf *bytes.Buffer, name string) {
+	buf.WriteString("</")
+	buf.WriteString(name)
+	buf.WriteByte('>')
+}
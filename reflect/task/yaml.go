@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// YAMLEncode returns v as YAML in the block style, with the fields named by
+// the "yaml" tag. Objects are indented mappings, lists are "- " items, and
+// strings are double-quoted where they'd read as something else.
+func YAMLEncode(v interface{}) ([]byte, error) {
+	n, err := walk(v, "yaml")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	switch {
+	case n.kind == objectNode && len(n.fields) > 0:
+		writeYAMLFields(&buf, n, 0, false)
+	case n.kind == listNode && len(n.items) > 0:
+		writeYAMLItems(&buf, n, 0)
+	default:
+		buf.WriteString(yamlFlow(n))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// writeYAMLFields writes the mapping of n at indent, the first key without
+// the indentation when it follows a "- ".
+func writeYAMLFields(buf *bytes.Buffer, n *node, indent int, inline bool) {
+	for i, f := range n.fields {
+		if i > 0 || !inline {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString(yamlString(f.name))
+		buf.WriteByte(':')
+		writeYAMLValue(buf, f.value, indent+2)
+	}
+}
+
+func writeYAMLItems(buf *bytes.Buffer, n *node, indent int) {
+	for _, item := range n.items {
+		buf.WriteString(strings.Repeat(" ", indent))
+		buf.WriteByte('-')
+		switch {
+		case item.kind == objectNode && len(item.fields) > 0:
+			buf.WriteByte(' ')
+			writeYAMLFields(buf, item, indent+2, true)
+		default:
+			writeYAMLValue(buf, item, indent+2)
+		}
+	}
+}
+
+// writeYAMLValue writes n after a "key:" or a "-".
+func writeYAMLValue(buf *bytes.Buffer, n *node, indent int) {
+	switch {
+	case n.kind == objectNode && len(n.fields) > 0:
+		buf.WriteByte('\n')
+		writeYAMLFields(buf, n, indent, false)
+	case n.kind == listNode && len(n.items) > 0:
+		buf.WriteByte('\n')
+		writeYAMLItems(buf, n, indent)
+	default:
+		buf.WriteByte(' ')
+		buf.WriteString(yamlFlow(n))
+		buf.WriteByte('\n')
+	}
+}
+
+// yamlFlow writes the values fitting on a line: scalars, null and the empty
+// object and list.
+func yamlFlow(n *node) string {
+	switch n.kind {
+	case nullNode:
+		return "null"
+	case objectNode:
+		return "{}"
+	case listNode:
+		return "[]"
+	}
+	if n.scalar != stringScalar {
+		return n.text
+	}
+	return yamlString(n.text)
+}
+
+// yamlString quotes s when it would read as another type, or as syntax.
+func yamlString(s string) string {
+	if yamlNeedsQuotes(s) {
+		return strconv.Quote(strings.ToValidUTF8(s, "\uFFFD"))
+	}
+	return s
+}
+
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n", ".inf", "-.inf", ".nan":
+		return true
+	}
+	// indicators, and what could start a number
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`+.0123456789", rune(s[0])) {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r == utf8.RuneError || r == '\u2028' || r == '\u2029' || r == '\ufeff' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -37,19 +37,21 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/awnzl/workshops/first/apperr"
+	"github.com/awnzl/workshops/first/validate"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Task struct {
-	ID        int64    `json:"id,omitempty"`
-	Alias     string   `json:"alias"`
-	Desc      string   `json:"desc"`
-	Category  []string `json:"cat,omitempty"`
-	Tags      []string `json:"tags,omitempty"`
-	Ts        int64    `json:"ts"`
-	EstTime   string   `json:"est_time"`
-	RealTime  string   `json:"real_time"`
-	Reminders []string `json:"reminders,omitempty"`
+	ID        int64    `json:"id,omitempty" validate:"min=0"`
+	Alias     string   `json:"alias" validate:"required,max=64"`
+	Desc      string   `json:"desc" validate:"max=1024"`
+	Category  []string `json:"cat,omitempty" validate:"dive,oneof=urgent important general"`
+	Tags      []string `json:"tags,omitempty" validate:"max=16,dive,required,max=32"`
+	Ts        int64    `json:"ts" validate:"min=0"`
+	EstTime   string   `json:"est_time" validate:"max=32"`
+	RealTime  string   `json:"real_time" validate:"max=32"`
+	Reminders []string `json:"reminders,omitempty" validate:"max=16,dive,required,max=64"`
 }
 
 type TaskList []Task
@@ -90,18 +92,36 @@ func (a *App) Create(w http.ResponseWriter, r *http.Request) {
 	var t Task
 	err := json.NewDecoder(r.Body).Decode(&t)
 	if err != nil {
-		log.Printf("Can't decode JSON: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.InvalidArgument, "can't decode JSON"))
+		return
+	}
+	if err = validateTask(t); err != nil {
+		apperr.WriteHTTP(w, err)
 		return
 	}
 	err = a.st.Create(t)
 	if err != nil {
-		log.Printf("Can't create new task: %v (%#v)\n", err, t)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.Internal, "can't create new task").With("alias", t.Alias))
 		return
 	}
 }
 
+// validateTask checks t against its validate tags, the broken rules are
+// the fields of the InvalidArgument error, by path.
+func validateTask(t Task) error {
+	err := validate.Struct(t)
+	if err == nil {
+		return nil
+	}
+	e := apperr.Wrap(err, apperr.InvalidArgument, "invalid task")
+	if errs, ok := err.(validate.Errors); ok {
+		for _, fe := range errs {
+			e = e.With(fe.Path, fe.Msg)
+		}
+	}
+	return e
+}
+
 func (a *App) Read(w http.ResponseWriter, r *http.Request) {
 	param := r.URL.Path[1:]
 	var tl TaskList
@@ -110,8 +130,7 @@ func (a *App) Read(w http.ResponseWriter, r *http.Request) {
 	if len(param) == 0 {
 		tl, err = a.st.read(nil)
 	} else if strings.IndexRune(param, '/') > -1 {
-		log.Printf("URL contains more than one parameter: %s\n", param)
-		http.Error(w, "URL contains more than one parameter", http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.New(apperr.InvalidArgument, "URL contains more than one parameter").With("path", param))
 		return
 	} else if id, err = strconv.ParseInt(param, 10, 64); err == nil {
 		tl, err = a.st.ReadById(&id)
@@ -119,15 +138,13 @@ func (a *App) Read(w http.ResponseWriter, r *http.Request) {
 		tl, err = a.st.ReadByAlias(&param)
 	}
 	if err != nil {
-		log.Printf("Some error in select: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.Internal, "can't read tasks"))
 		return
 	}
 	var js []byte
 	js, err = json.Marshal(tl)
 	if err != nil {
-		log.Printf("Couldn't marshal list of tasks: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.Internal, "couldn't marshal list of tasks"))
 		return
 	}
 	w.Write(js)
@@ -138,26 +155,26 @@ func (a *App) Update(w http.ResponseWriter, r *http.Request) {
 	var err error
 	var id int64
 	if id, err = strconv.ParseInt(r.URL.Path[1:], 10, 64); err != nil {
-		log.Printf("URL didn't contains ID as parameter: %s\n", r.URL.Path)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.InvalidArgument, "URL doesn't contain ID as parameter").With("path", r.URL.Path))
 		return
 	}
 	var t Task
 	err = json.NewDecoder(r.Body).Decode(&t)
 	if err != nil {
-		log.Printf("Can't decode JSON: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.InvalidArgument, "can't decode JSON"))
 		return
 	}
 	if id != t.ID {
-		log.Printf("ID from URL and JSON are different: %d <-> %d\n", id, t.ID)
-		http.Error(w, "ID not match", http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.New(apperr.InvalidArgument, "ID not match").With("url_id", id).With("json_id", t.ID))
+		return
+	}
+	if err = validateTask(t); err != nil {
+		apperr.WriteHTTP(w, err)
 		return
 	}
 	err = a.st.Update(t)
 	if err != nil {
-		log.Printf("Error while update of task: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.Internal, "can't update task").With("id", id))
 		return
 	}
 }
@@ -166,15 +183,13 @@ func (a *App) Delete(w http.ResponseWriter, r *http.Request) {
 	var id int64
 	var err error
 	if id, err = strconv.ParseInt(r.URL.Path[1:], 10, 64); err != nil {
-		log.Printf("URL didn't contains ID as parameter: %s\n", r.URL.Path)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.InvalidArgument, "URL doesn't contain ID as parameter").With("path", r.URL.Path))
 		return
 	}
 	t := Task{ID: id}
 	err = a.st.Delete(t)
 	if err != nil {
-		log.Printf("Can't delete the Task(%d): %v", id, err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.WriteHTTP(w, apperr.Wrap(err, apperr.InvalidArgument, "can't delete the task").With("id", id))
 		return
 	}
 }